@@ -0,0 +1,77 @@
+package juice
+
+import (
+	er "errors"
+	"fmt"
+)
+
+// ErrSandboxOnly is returned, before anything is sent, when a client
+// configured for production calls an endpoint that only exists in the sandbox.
+var ErrSandboxOnly = er.New("juice: endpoint is only available in the sandbox")
+
+// Endpoint names a Spend-Juice API operation.
+type Endpoint string
+
+const (
+	EndpointRegisterAccount  Endpoint = "RegisterAccount"
+	EndpointUpdateAccount    Endpoint = "UpdateAccount"
+	EndpointTopUpFloat       Endpoint = "TopUpFloat"
+	EndpointGetFloat         Endpoint = "GetFloat"
+	EndpointRegisterUser     Endpoint = "RegisterUser"
+	EndpointListUsers        Endpoint = "ListUsers"
+	EndpointSearchUsers      Endpoint = "SearchUsers"
+	EndpointGetUser          Endpoint = "GetUser"
+	EndpointUpdateUser       Endpoint = "UpdateUser"
+	EndpointArchiveUser      Endpoint = "ArchiveUser"
+	EndpointUnarchiveUser    Endpoint = "UnarchiveUser"
+	EndpointCreateCard       Endpoint = "CreateCard"
+	EndpointListCards        Endpoint = "ListCards"
+	EndpointGetCard          Endpoint = "GetCard"
+	EndpointCreditCard       Endpoint = "CreditCard"
+	EndpointDebitCard        Endpoint = "DebitCard"
+	EndpointFreezeCard       Endpoint = "FreezeCard"
+	EndpointUnfreezeCard     Endpoint = "UnfreezeCard"
+	EndpointTerminateCard    Endpoint = "TerminateCard"
+	EndpointListTransactions Endpoint = "ListTransactions"
+	EndpointGetTransaction   Endpoint = "GetTransaction"
+	EndpointMockTransaction  Endpoint = "MockTransaction"
+)
+
+// capabilities lists the environments each endpoint is available in.
+// Endpoints missing from the map are available everywhere.
+var capabilities = map[Endpoint][]Environment{
+	EndpointTopUpFloat:      {Sandbox},
+	EndpointMockTransaction: {Sandbox},
+}
+
+// Supports reports whether the endpoint is available in the environment.
+func (e Environment) Supports(ep Endpoint) bool {
+	envs, ok := capabilities[ep]
+	if !ok {
+		return true
+	}
+	for _, env := range envs {
+		if env == e {
+			return true
+		}
+	}
+	return false
+}
+
+// SandboxOnly reports whether the endpoint is missing from production.
+func (ep Endpoint) SandboxOnly() bool {
+	return Sandbox.Supports(ep) && !Production.Supports(ep)
+}
+
+// Environment returns the environment the client was created for.
+func (cl *Client) Environment() Environment {
+	return cl.environment
+}
+
+// require fails with ErrSandboxOnly when the client's environment lacks ep.
+func (cl *Client) require(ep Endpoint) error {
+	if !cl.environment.Supports(ep) {
+		return fmt.Errorf("%w: %s", ErrSandboxOnly, ep)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package juice
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestClient_sandboxOnly(t *testing.T) {
+	c, err := NewClient(WithAPIKey("sk_live_123"), WithEnvironment(Production))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	c.SetHTTPClient(&MockHttpClient{
+		DoFunc: func(r *http.Request) (*http.Response, error) {
+			t.Fatalf("%s %s was sent to production", r.Method, r.URL.Path)
+			return nil, nil
+		},
+	})
+
+	if _, err := c.TopUpFloat(USDCents(500000)); !errors.Is(err, ErrSandboxOnly) {
+		t.Errorf("TopUpFloat() error = %v, want ErrSandboxOnly", err)
+	}
+	if _, err := c.MockTransaction(MockTransactionData{Amount: USDCents(100), Type: "debit"}, "card"); !errors.Is(err, ErrSandboxOnly) {
+		t.Errorf("MockTransaction() error = %v, want ErrSandboxOnly", err)
+	}
+}
+
+func TestEnvironment_Supports(t *testing.T) {
+	tests := []struct {
+		env  Environment
+		ep   Endpoint
+		want bool
+	}{
+		{env: Sandbox, ep: EndpointTopUpFloat, want: true},
+		{env: Production, ep: EndpointTopUpFloat, want: false},
+		{env: Production, ep: EndpointMockTransaction, want: false},
+		{env: Production, ep: EndpointCreditCard, want: true},
+	}
+	for _, tt := range tests {
+		if got := tt.env.Supports(tt.ep); got != tt.want {
+			t.Errorf("%s.Supports(%s) = %v, want %v", tt.env, tt.ep, got, tt.want)
+		}
+	}
+	if !EndpointMockTransaction.SandboxOnly() || EndpointGetCard.SandboxOnly() {
+		t.Errorf("SandboxOnly() disagrees with the capability registry")
+	}
+}
@@ -2,17 +2,15 @@ package juice
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	er "errors"
 	"github.com/google/go-querystring/query"
 	"io"
-	"log"
 	"net/http"
-	"os"
 	"strings"
+	"sync/atomic"
 	"time"
-
-	valid "github.com/asaskevich/govalidator"
 )
 
 const (
@@ -26,24 +24,66 @@ type HTTPClient interface {
 
 // Client ...
 type Client struct {
-	httpClient HTTPClient
-	baseURL    string
-	apiVersion string
-	apiKey     string
-	debug      bool
+	httpClient  HTTPClient
+	environment Environment
+	baseURL     string
+	apiVersion  string
+	apiKey      string
+	logger      Logger
+	logLevel    Level
+	retryPolicy RetryPolicy
+	limiter     *limiter
+	middleware  []Middleware
+	decoders    map[string]ResponseDecoder
+	// serverVersion holds the last API version the server reported.
+	serverVersion atomic.Value
 }
 
-// NewClient creates a new Spend-Juice API client with the default base URL.
-func NewClient() *Client {
-	return &Client{
-		httpClient: &http.Client{Timeout: defaultTimeout},
-		baseURL:    defaultBaseURL,
-		apiKey:     os.Getenv("JUICE_PRIVATE_KEY"),
-		debug:      os.Getenv("ENV") != "production",
+// NewClient creates a new Spend-Juice API client. It talks to the sandbox
+// unless WithEnvironment(Production) is given, and fails when the API key is
+// missing or is a sandbox key used in production:
+//
+//	cl, err := juice.NewClient(juice.WithAPIKey(key), juice.WithEnvironment(juice.Production))
+func NewClient(opts ...Option) (*Client, error) {
+	o := options{environment: Sandbox}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+
+	cl := &Client{
+		httpClient:  &http.Client{Timeout: defaultTimeout},
+		environment: o.environment,
+		baseURL:     o.environment.BaseURL(),
+		logger:      NewStdLogger(nil),
+		logLevel:    LevelDebug,
+		retryPolicy: DefaultRetryPolicy,
+	}
+	if o.environment == Production {
+		cl.logLevel = LevelInfo
+	}
+	cl.SetAuth(o.apiKey)
+	if o.baseURL != "" {
+		cl.SetBaseURL(o.baseURL)
+	}
+	if o.httpClient != nil {
+		cl.httpClient = o.httpClient
+	}
+	if o.timeout != 0 {
+		httpClient := *cl.httpClient.(*http.Client)
+		httpClient.Timeout = o.timeout
+		cl.httpClient = &httpClient
 	}
+	if o.logger != nil {
+		cl.logger = o.logger
+	}
+	cl.apiVersion = o.apiVersion
+	return cl, nil
 }
 
-//SetAuth provides the client with an API key and secret.
+// SetAuth provides the client with an API key and secret.
 func (cl *Client) SetAuth(apiKey string) error {
 	if apiKey == "" {
 		return er.New("juice: no credentials provided")
@@ -68,21 +108,28 @@ func (cl *Client) SetBaseURL(baseURL string) {
 	cl.baseURL = strings.TrimRight(baseURL, "/")
 }
 
-//SetAPIVersion overrides the default base URL. For internal use.
+// SetAPIVersion pins the API version sent with every request in the
+// X-Api-Version header. The server's default applies when it is empty.
 func (cl *Client) SetAPIVersion(version string) {
 	cl.apiVersion = version
 }
 
 // SetDebug enables or disables debug mode. In debug mode, HTTP requests and
 // responses will be logged.
+//
+// Deprecated: use SetLogLevel(LevelDebug) or SetLogLevel(LevelInfo).
 func (cl *Client) SetDebug(debug bool) {
-	cl.debug = debug
+	if debug {
+		cl.logLevel = LevelDebug
+	} else {
+		cl.logLevel = LevelInfo
+	}
 }
 
-func (cl *Client) get(path string, params interface{}, response interface{}) (err error) {
+func (cl *Client) get(ctx context.Context, path string, params interface{}, response interface{}) (err error) {
 	if params != nil {
 
-		_, err = valid.ValidateStruct(params)
+		err = validate(params)
 		if err != nil {
 			return
 		}
@@ -93,12 +140,9 @@ func (cl *Client) get(path string, params interface{}, response interface{}) (er
 
 	url := cl.baseURL + "/" + strings.TrimLeft(path, "/")
 
-	if cl.debug {
-		log.Printf("juice: Call: %s %s", "GET", url)
-		log.Printf("juice: Request Params: %#v", params)
-	}
+	cl.logParams(http.MethodGet, url, params)
 
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 
 	if err != nil {
 		return err
@@ -107,14 +151,14 @@ func (cl *Client) get(path string, params interface{}, response interface{}) (er
 	return cl.request(req, response)
 }
 
-func (cl *Client) post(path string, params interface{}, response interface{}) (err error) {
+func (cl *Client) post(ctx context.Context, path string, params interface{}, response interface{}) (err error) {
 	url := cl.baseURL + "/" + strings.TrimLeft(path, "/")
 
 	var req *http.Request
 	var bodyBuffered io.Reader
 
 	if params != nil {
-		_, err = valid.ValidateStruct(params)
+		err = validate(params)
 		if err != nil {
 			return
 		}
@@ -124,28 +168,29 @@ func (cl *Client) post(path string, params interface{}, response interface{}) (e
 
 	}
 
-	if cl.debug {
-		log.Printf("juice: Call: %s %s", "POST", url)
-		log.Printf("juice: Request Params: %#v", params)
-	}
+	cl.logParams(http.MethodPost, url, params)
 
-	req, err = http.NewRequest(http.MethodPost, url, bodyBuffered)
+	req, err = http.NewRequestWithContext(ctx, http.MethodPost, url, bodyBuffered)
 
 	if err != nil {
 		return
 	}
 
+	if k, ok := params.(idempotent); ok {
+		req.Header.Set(idempotencyKeyHeader, k.idempotencyKey())
+	}
+
 	return cl.request(req, response)
 }
 
-func (cl *Client) patch(path string, params interface{}, response interface{}) (err error) {
+func (cl *Client) patch(ctx context.Context, path string, params interface{}, response interface{}) (err error) {
 	url := cl.baseURL + "/" + strings.TrimLeft(path, "/")
 
 	var req *http.Request
 	var bodyBuffered io.Reader
 
 	if params != nil {
-		_, err = valid.ValidateStruct(params)
+		err = validate(params)
 		if err != nil {
 			return
 		}
@@ -155,44 +200,78 @@ func (cl *Client) patch(path string, params interface{}, response interface{}) (
 
 	}
 
-	if cl.debug {
-		log.Printf("juice: Call: %s %s", "PATCH", path)
-		log.Printf("juice: Request Params: %#v", params)
-	}
+	cl.logParams(http.MethodPatch, url, params)
 
-	req, err = http.NewRequest(http.MethodPatch, url, bodyBuffered)
+	req, err = http.NewRequestWithContext(ctx, http.MethodPatch, url, bodyBuffered)
 
 	if err != nil {
 		return
 	}
 
+	if k, ok := params.(idempotent); ok {
+		req.Header.Set(idempotencyKeyHeader, k.idempotencyKey())
+	}
+
 	return cl.request(req, response)
 }
 
 func (cl *Client) request(req *http.Request, response interface{}) (err error) {
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("authorization", cl.apiKey)
-
-	r, err := cl.httpClient.Do(req)
+	r, err := cl.do(req)
 
 	if err != nil {
 		return
 	}
 
 	defer r.Body.Close()
+	version := cl.observeVersion(r)
 
 	if r.StatusCode < 200 || r.StatusCode >= 300 {
-		e := Error{}
-		err = json.NewDecoder(r.Body).Decode(&e)
-
+		e := &APIError{
+			StatusCode:  r.StatusCode,
+			RequestId:   r.Header.Get(requestIdHeader),
+			Method:      req.Method,
+			Endpoint:    req.URL.Path,
+			ContentType: r.Header.Get("Content-Type"),
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxErrorBody))
 		if err != nil {
 			return err
 		}
 
-		return e
+		// Gateways in front of the API answer with HTML or plain text; keep
+		// what they said rather than failing on the first '<'.
+		if json.NewDecoder(bytes.NewReader(body)).Decode(e) != nil || (e.Message == "" && e.Errors == nil) {
+			e.Message = http.StatusText(r.StatusCode)
+			e.Errors = nil
+			e.Body = truncate(body)
+		}
+
+		e.parse()
+		return *e
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if cl.logs(LevelDebug) {
+		cl.log(LevelDebug, "response", Field{"status", r.StatusCode}, Field{"body", redactJSON(body)})
+	}
+	// Only a 204, or a call that expects no result, may come back empty; an
+	// empty 200 fails to decode below.
+	if r.StatusCode == http.StatusNoContent || response == nil {
+		return nil
 	}
 
-	err = json.NewDecoder(r.Body).Decode(response)
-	return
+	if err = cl.decoder(version)(body, response); err != nil {
+		return &DecodeError{
+			StatusCode:  r.StatusCode,
+			Method:      req.Method,
+			Endpoint:    req.URL.Path,
+			ContentType: r.Header.Get("Content-Type"),
+			Body:        truncate(body),
+			Err:         err,
+		}
+	}
+	return nil
 }
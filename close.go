@@ -0,0 +1,136 @@
+package juice
+
+import (
+	"context"
+	"fmt"
+)
+
+// CloseStep is one step of closing a card.
+type CloseStep string
+
+const (
+	// CloseFreeze stops the card from being used while it is closed.
+	CloseFreeze CloseStep = "freeze"
+	// CloseSweep debits the remaining balance back to the float.
+	CloseSweep CloseStep = "sweep"
+	// CloseTerminate permanently terminates the card.
+	CloseTerminate CloseStep = "terminate"
+)
+
+// closeSteps is the order CloseCard runs its steps in.
+var closeSteps = []CloseStep{CloseFreeze, CloseSweep, CloseTerminate}
+
+// StepState is how far a CloseStep got.
+type StepState string
+
+const (
+	StepPending StepState = "pending"
+	StepDone    StepState = "done"
+	// StepSkipped is a step that had nothing to do, e.g. because an earlier
+	// CloseCard call already completed it.
+	StepSkipped StepState = "skipped"
+	StepFailed  StepState = "failed"
+)
+
+// StepResult reports one step of CloseCard.
+type StepResult struct {
+	Step  CloseStep
+	State StepState
+	Err   error
+}
+
+// CloseReport is what CloseCard did.
+type CloseReport struct {
+	CardId string
+	Steps  []StepResult
+	// Swept is the balance moved back to the float by this call.
+	Swept Money
+	// Card is the card as of the last step that ran.
+	Card CardResp
+}
+
+// Done reports whether the card is terminated.
+func (r CloseReport) Done() bool {
+	for _, s := range r.Steps {
+		if s.State != StepDone && s.State != StepSkipped {
+			return false
+		}
+	}
+	return len(r.Steps) > 0
+}
+
+// CloseCard freezes a card, sweeps its balance back to the float with
+// DebitCard and terminates it, reporting each step.
+//
+// It is safe to call again after a failure: steps the card's current state
+// shows as complete are skipped. The sweep's idempotency key is derived from
+// the card's latest transaction, so repeating a sweep whose response was lost
+// is applied once, while a balance credited after an earlier sweep gets a key
+// of its own.
+func (cl *Client) CloseCard(ctx context.Context, cardId string) (CloseReport, error) {
+	report := CloseReport{CardId: cardId}
+	for _, step := range closeSteps {
+		report.Steps = append(report.Steps, StepResult{Step: step, State: StepPending})
+	}
+
+	card, err := cl.GetCardCtx(ctx, cardId)
+	if err != nil {
+		return report, fmt.Errorf("juice: closing card %s: %w", cardId, err)
+	}
+	report.Card = card
+	report.Swept = NewMoney(0, card.Balance.Currency)
+
+	for i, step := range closeSteps {
+		state, err := cl.closeStep(ctx, step, &report)
+		report.Steps[i].State, report.Steps[i].Err = state, err
+		if err != nil {
+			return report, fmt.Errorf("juice: closing card %s: %s: %w", cardId, step, err)
+		}
+	}
+	return report, nil
+}
+
+func (cl *Client) closeStep(ctx context.Context, step CloseStep, report *CloseReport) (StepState, error) {
+	card := report.Card
+	if card.Status == CardTerminated {
+		return StepSkipped, nil
+	}
+
+	var err error
+	switch step {
+	case CloseFreeze:
+		if card.Status == CardFrozen {
+			return StepSkipped, nil
+		}
+		card, err = cl.FreezeCardCtx(ctx, report.CardId)
+	case CloseSweep:
+		if card.Balance.Amount <= 0 {
+			return StepSkipped, nil
+		}
+		var history TransactionsResp
+		if history, err = cl.ListTransactionsCtx(ctx, report.CardId, Param{Limit: 1, Page: 1}); err != nil {
+			return StepFailed, err
+		}
+		last := ""
+		if len(history.Data) > 0 {
+			last = history.Data[0].Id
+		}
+		swept := card.Balance
+		card, err = cl.DebitCardCtx(ctx, PaymentData{
+			Source:         "integrator",
+			Amount:         swept,
+			CardId:         report.CardId,
+			IdempotencyKey: fmt.Sprintf("close-%s-%s-%d", report.CardId, last, swept.Amount),
+		})
+		if err == nil {
+			report.Swept = swept
+		}
+	case CloseTerminate:
+		card, err = cl.TerminateCardCtx(ctx, report.CardId)
+	}
+	if err != nil {
+		return StepFailed, err
+	}
+	report.Card = card
+	return StepDone, nil
+}
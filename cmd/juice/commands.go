@@ -0,0 +1,509 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	juice "github.com/bushaHQ/spend-juice-go"
+)
+
+var commands = []command{
+	{group: "account", name: "register", help: "register a card integrator account", endpoint: juice.EndpointRegisterAccount, run: accountRegister},
+	{group: "account", name: "update", help: "update the webhook URL, business address or domain", endpoint: juice.EndpointUpdateAccount, run: accountUpdate},
+	{group: "float", name: "get", help: "show the float balance", endpoint: juice.EndpointGetFloat, run: floatGet},
+	{group: "float", name: "topup", args: "<amount>", help: "top up the float", endpoint: juice.EndpointTopUpFloat, run: floatTopUp},
+	{group: "users", name: "register", args: "<account-id>", help: "register a card user", endpoint: juice.EndpointRegisterUser, run: usersRegister},
+	{group: "users", name: "list", help: "list card users", endpoint: juice.EndpointListUsers, run: usersList},
+	{group: "users", name: "search", args: "<email>", help: "find card users by email", endpoint: juice.EndpointSearchUsers, run: usersSearch},
+	{group: "users", name: "get", args: "<user-id>", help: "show a card user", endpoint: juice.EndpointGetUser, run: usersGet},
+	{group: "users", name: "update", args: "<user-id>", help: "change a card user's phone number or address", endpoint: juice.EndpointUpdateUser, run: usersUpdate},
+	{group: "users", name: "archive", args: "<user-id>", help: "archive a card user", endpoint: juice.EndpointArchiveUser, run: usersArchive},
+	{group: "users", name: "unarchive", args: "<user-id>", help: "restore an archived card user", endpoint: juice.EndpointUnarchiveUser, run: usersUnarchive},
+	{group: "cards", name: "create", args: "<user-id>", help: "create a virtual card", endpoint: juice.EndpointCreateCard, run: cardsCreate},
+	{group: "cards", name: "list", args: "<user-id>", help: "list a user's cards", endpoint: juice.EndpointListCards, run: cardsList},
+	{group: "cards", name: "get", args: "<card-id>", help: "show a card", endpoint: juice.EndpointGetCard, run: cardsGet},
+	{group: "cards", name: "credit", args: "<card-id> <amount>", help: "move money from the float to a card", endpoint: juice.EndpointCreditCard, run: cardsCredit},
+	{group: "cards", name: "debit", args: "<card-id> <amount>", help: "move money from a card to the float", endpoint: juice.EndpointDebitCard, run: cardsDebit},
+	{group: "cards", name: "freeze", args: "<card-id>", help: "freeze a card", endpoint: juice.EndpointFreezeCard, run: cardsFreeze},
+	{group: "cards", name: "unfreeze", args: "<card-id>", help: "unfreeze a card", endpoint: juice.EndpointUnfreezeCard, run: cardsUnfreeze},
+	{group: "cards", name: "terminate", args: "<card-id>", help: "permanently terminate a card with a zero balance", endpoint: juice.EndpointTerminateCard, run: cardsTerminate},
+	{group: "cards", name: "close", args: "<card-id>", help: "freeze a card, sweep its balance to the float and terminate it", endpoint: juice.EndpointTerminateCard, run: cardsClose},
+	{group: "tx", name: "list", args: "<card-id>", help: "list a card's transactions", endpoint: juice.EndpointListTransactions, run: txList},
+	{group: "tx", name: "get", args: "<transaction-id>", help: "show a transaction", endpoint: juice.EndpointGetTransaction, run: txGet},
+	{group: "tx", name: "mock", args: "<card-id> <amount>", help: "simulate a card transaction", endpoint: juice.EndpointMockTransaction, run: txMock},
+}
+
+// parse parses flags and positional arguments in any order and checks that
+// exactly the named positional arguments were given.
+func parse(fs *flag.FlagSet, args []string, names ...string) ([]string, error) {
+	fs.SetOutput(ioutil.Discard)
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if len(positional) != len(names) {
+		if len(names) == 0 {
+			return nil, fmt.Errorf("unexpected arguments %v", positional)
+		}
+		return nil, fmt.Errorf("expected <%s>", strings.Join(names, "> <"))
+	}
+	return positional, nil
+}
+
+// parseAmount parses "12.50 USD", or "12.50" as USD.
+func parseAmount(s string) (juice.Money, error) {
+	if len(strings.Fields(s)) == 1 {
+		s += " " + string(juice.DefaultCurrency)
+	}
+	amount, err := juice.ParseMoney(s)
+	if err != nil {
+		return juice.Money{}, err
+	}
+	if amount.Amount <= 0 {
+		return juice.Money{}, fmt.Errorf("amount must be positive, got %s", amount)
+	}
+	return amount, nil
+}
+
+func accountRegister(e *env, args []string) error {
+	fs := flag.NewFlagSet("account register", flag.ContinueOnError)
+	var data juice.RegisterAccountData
+	fs.StringVar(&data.BusinessName, "business-name", "", "business name")
+	fs.StringVar(&data.BusinessAddress, "business-address", "", "business address")
+	chain := fs.String("chain", "ETH", "USDC funding chain")
+	fs.StringVar(&data.ContactNumber, "contact-number", "", "contact phone number")
+	fs.StringVar(&data.Country, "country", "", "ISO country code")
+	fs.StringVar(&data.Domain, "domain", "", "business website")
+	fs.StringVar(&data.Email, "email", "", "contact email")
+	fs.StringVar(&data.FirstName, "first-name", "", "contact first name")
+	fs.StringVar(&data.LastName, "last-name", "", "contact last name")
+	fs.StringVar(&data.Password, "password", "", "account password")
+	fs.StringVar(&data.RegistrationNumber, "registration-number", "", "company registration number")
+	fs.StringVar(&data.WebhookUrl, "webhook-url", "", "webhook URL")
+	currencies := fs.String("float-currencies", "USD", "comma separated float currencies")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	data.Chain = juice.Chain(*chain)
+	for _, c := range strings.Split(*currencies, ",") {
+		data.FloatCurrencies = append(data.FloatCurrencies, juice.Currency(c))
+	}
+
+	res, err := e.cl.RegisterAccountCtx(e.ctx, data)
+	if err != nil {
+		return err
+	}
+	return e.printAccount(res.Data)
+}
+
+func accountUpdate(e *env, args []string) error {
+	fs := flag.NewFlagSet("account update", flag.ContinueOnError)
+	webhook := fs.String("webhook-url", "", "webhook URL")
+	address := fs.String("business-address", "", "business address")
+	domain := fs.String("domain", "", "business website")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+
+	res, err := e.cl.UpdateAccountCtx(e.ctx, *webhook, *address, *domain)
+	if err != nil {
+		return err
+	}
+	return e.printAccount(res.Data)
+}
+
+func floatGet(e *env, args []string) error {
+	if _, err := parse(flag.NewFlagSet("float get", flag.ContinueOnError), args); err != nil {
+		return err
+	}
+	res, err := e.cl.GetFloatCtx(e.ctx)
+	if err != nil {
+		return err
+	}
+	return e.print(res, []string{"ID", "BALANCE", "CURRENCY"}, [][]string{{res.Id, res.Balance.Decimal(), string(res.Currency)}})
+}
+
+func floatTopUp(e *env, args []string) error {
+	pos, err := parse(flag.NewFlagSet("float topup", flag.ContinueOnError), args, "amount")
+	if err != nil {
+		return err
+	}
+	amount, err := parseAmount(pos[0])
+	if err != nil {
+		return err
+	}
+	res, err := e.cl.TopUpFloatCtx(e.ctx, amount)
+	if err != nil {
+		return err
+	}
+	return e.print(res, []string{"MESSAGE"}, [][]string{{res.Message}})
+}
+
+func usersRegister(e *env, args []string) error {
+	fs := flag.NewFlagSet("users register", flag.ContinueOnError)
+	var data juice.RegisterUserData
+	var line2, state string
+	fs.StringVar(&data.Email, "email", "", "email address")
+	fs.StringVar(&data.FirstName, "first-name", "", "first name")
+	fs.StringVar(&data.LastName, "last-name", "", "last name")
+	fs.StringVar(&data.PhoneNumber, "phone", "", "phone number in international format")
+	idType := fs.String("id-type", "BVN", "identity document type")
+	fs.StringVar(&data.IdNumber, "id-number", "", "identity document number")
+	fs.StringVar(&data.Address.Line1, "line1", "", "address line 1")
+	fs.StringVar(&line2, "line2", "", "address line 2")
+	fs.StringVar(&data.Address.City, "city", "", "city")
+	fs.StringVar(&state, "state", "", "state")
+	fs.StringVar(&data.Address.Country, "country", "", "ISO country code")
+	fs.StringVar(&data.Address.ZipCode, "zip", "", "zip code")
+	pos, err := parse(fs, args, "account-id")
+	if err != nil {
+		return err
+	}
+	data.IdType = juice.IdType(*idType)
+	if line2 != "" {
+		data.Address.Line2 = line2
+	}
+	if state != "" {
+		data.Address.State = state
+	}
+
+	res, err := e.cl.RegisterUserCtx(e.ctx, data, pos[0])
+	if err != nil {
+		return err
+	}
+	return e.printUsers(res, []juice.User{res.Data})
+}
+
+func usersList(e *env, args []string) error {
+	fs := flag.NewFlagSet("users list", flag.ContinueOnError)
+	limit := fs.Int("limit", 50, "users per page")
+	page := fs.Int("page", 0, "page to show; all pages when 0")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if *page > 0 {
+		res, err := e.cl.ListUsersCtx(e.ctx, *limit, *page)
+		if err != nil {
+			return err
+		}
+		return e.printUsers(res, res.Data)
+	}
+	users, err := e.cl.Users(e.ctx, *limit).All()
+	if err != nil {
+		return err
+	}
+	return e.printUsers(users, users)
+}
+
+func usersSearch(e *env, args []string) error {
+	fs := flag.NewFlagSet("users search", flag.ContinueOnError)
+	limit := fs.Int("limit", 50, "users per page")
+	page := fs.Int("page", 1, "page to show")
+	pos, err := parse(fs, args, "email")
+	if err != nil {
+		return err
+	}
+	res, err := e.cl.SearchUsersCtx(e.ctx, pos[0], *limit, *page)
+	if err != nil {
+		return err
+	}
+	return e.printUsers(res, res.Data)
+}
+
+func usersGet(e *env, args []string) error {
+	return userAction(e, "users get", args, e.cl.GetUserCtx)
+}
+
+func usersUpdate(e *env, args []string) error {
+	fs := flag.NewFlagSet("users update", flag.ContinueOnError)
+	var data juice.UpdateUserData
+	var address juice.UserAddress
+	var line2, state string
+	fs.StringVar(&data.PhoneNumber, "phone", "", "phone number in international format")
+	fs.StringVar(&address.Line1, "line1", "", "address line 1")
+	fs.StringVar(&line2, "line2", "", "address line 2")
+	fs.StringVar(&address.City, "city", "", "city")
+	fs.StringVar(&state, "state", "", "state")
+	fs.StringVar(&address.Country, "country", "", "ISO country code")
+	fs.StringVar(&address.ZipCode, "zip", "", "zip code")
+	pos, err := parse(fs, args, "user-id")
+	if err != nil {
+		return err
+	}
+	if line2 != "" {
+		address.Line2 = line2
+	}
+	if state != "" {
+		address.State = state
+	}
+	if address != (juice.UserAddress{}) {
+		data.Address = &address
+	}
+
+	res, err := e.cl.UpdateUserCtx(e.ctx, pos[0], data)
+	if err != nil {
+		return err
+	}
+	return e.printUsers(res, []juice.User{res.Data})
+}
+
+func usersArchive(e *env, args []string) error {
+	return userAction(e, "users archive", args, e.cl.ArchiveUserCtx)
+}
+
+func usersUnarchive(e *env, args []string) error {
+	return userAction(e, "users unarchive", args, e.cl.UnarchiveUserCtx)
+}
+
+func userAction(e *env, name string, args []string, call func(ctx context.Context, userId string) (juice.UserResp, error)) error {
+	pos, err := parse(flag.NewFlagSet(name, flag.ContinueOnError), args, "user-id")
+	if err != nil {
+		return err
+	}
+	res, err := call(e.ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	return e.printUsers(res, []juice.User{res.Data})
+}
+
+func cardsCreate(e *env, args []string) error {
+	fs := flag.NewFlagSet("cards create", flag.ContinueOnError)
+	var data juice.CreateCardData
+	fs.StringVar(&data.CardIntegratorId, "integrator", "", "card integrator account id")
+	currency := fs.String("currency", "USD", "card currency")
+	design := fs.String("design", string(juice.DesignAurora), "card design")
+	fs.StringVar(&data.Source, "source", "integrator", "funding source")
+	fs.IntVar(&data.Validity, "validity", 30, "validity in days")
+	fs.BoolVar(&data.SingleUse, "single-use", false, "create a single-use card")
+	fs.StringVar(&data.IdempotencyKey, "idempotency-key", "", "key making a repeated create safe")
+	pos, err := parse(fs, args, "user-id")
+	if err != nil {
+		return err
+	}
+	data.UserId = pos[0]
+	data.Currency = juice.Currency(*currency)
+	data.DesignType = juice.DesignType(*design)
+
+	res, err := e.cl.CreateCardCtx(e.ctx, data)
+	if err != nil {
+		return err
+	}
+	c := res.Data
+	return e.print(res, cardHeader, [][]string{{c.Id, string(c.Status), c.Balance.String(), string(c.CardType), c.Valid}})
+}
+
+func cardsList(e *env, args []string) error {
+	fs := flag.NewFlagSet("cards list", flag.ContinueOnError)
+	limit := fs.Int("limit", 50, "cards per page")
+	page := fs.Int("page", 0, "page to show; all pages when 0")
+	pos, err := parse(fs, args, "user-id")
+	if err != nil {
+		return err
+	}
+
+	var cards []juice.CardResp
+	if *page > 0 {
+		cards, err = e.cl.ListCardsCtx(e.ctx, *limit, *page, pos[0])
+	} else {
+		cards, err = e.cl.Cards(e.ctx, pos[0], *limit).All()
+	}
+	if err != nil {
+		return err
+	}
+	return e.printCards(cards, cards...)
+}
+
+func cardsGet(e *env, args []string) error {
+	return cardAction(e, "cards get", args, e.cl.GetCardCtx)
+}
+
+func cardsFreeze(e *env, args []string) error {
+	return cardAction(e, "cards freeze", args, e.cl.FreezeCardCtx)
+}
+
+func cardsUnfreeze(e *env, args []string) error {
+	return cardAction(e, "cards unfreeze", args, e.cl.UnfreezeCardCtx)
+}
+
+func cardsCredit(e *env, args []string) error {
+	return payment(e, "cards credit", args, e.cl.CreditCardCtx)
+}
+
+func cardsDebit(e *env, args []string) error {
+	return payment(e, "cards debit", args, e.cl.DebitCardCtx)
+}
+
+func txList(e *env, args []string) error {
+	fs := flag.NewFlagSet("tx list", flag.ContinueOnError)
+	limit := fs.Int("limit", 50, "transactions per page")
+	page := fs.Int("page", 0, "page to show; all pages when 0")
+	pos, err := parse(fs, args, "card-id")
+	if err != nil {
+		return err
+	}
+
+	if *page > 0 {
+		res, err := e.cl.ListTransactionsCtx(e.ctx, pos[0], juice.Param{Limit: *limit, Page: *page})
+		if err != nil {
+			return err
+		}
+		return e.printTransactions(res, res.Data...)
+	}
+	txs, err := e.cl.Transactions(e.ctx, pos[0], *limit).All()
+	if err != nil {
+		return err
+	}
+	return e.printTransactions(txs, txs...)
+}
+
+func txGet(e *env, args []string) error {
+	pos, err := parse(flag.NewFlagSet("tx get", flag.ContinueOnError), args, "transaction-id")
+	if err != nil {
+		return err
+	}
+	res, err := e.cl.GetTransactionCtx(e.ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	return e.printTransactions(res, res.Data)
+}
+
+func txMock(e *env, args []string) error {
+	fs := flag.NewFlagSet("tx mock", flag.ContinueOnError)
+	kind := fs.String("type", "debit", "transaction type")
+	pos, err := parse(fs, args, "card-id", "amount")
+	if err != nil {
+		return err
+	}
+	amount, err := parseAmount(pos[1])
+	if err != nil {
+		return err
+	}
+	res, err := e.cl.MockTransactionCtx(e.ctx, juice.MockTransactionData{Amount: amount, Type: juice.TransactionType(*kind)}, pos[0])
+	if err != nil {
+		return err
+	}
+	return e.print(res, []string{"MESSAGE"}, [][]string{{res.Message}})
+}
+
+func cardsTerminate(e *env, args []string) error {
+	return cardAction(e, "cards terminate", args, e.cl.TerminateCardCtx)
+}
+
+// cardsClose prints every step of the close, then fails if one did. Running
+// it again resumes a failed close.
+func cardsClose(e *env, args []string) error {
+	pos, err := parse(flag.NewFlagSet("cards close", flag.ContinueOnError), args, "card-id")
+	if err != nil {
+		return err
+	}
+	report, closeErr := e.cl.CloseCard(e.ctx, pos[0])
+
+	type step struct {
+		Step   juice.CloseStep `json:"step"`
+		State  juice.StepState `json:"state"`
+		Detail string          `json:"detail,omitempty"`
+	}
+	var steps []step
+	var rows [][]string
+	for _, s := range report.Steps {
+		detail := ""
+		switch {
+		case s.Err != nil:
+			detail = s.Err.Error()
+		case s.Step == juice.CloseSweep && s.State == juice.StepDone:
+			detail = report.Swept.String() + " to float"
+		}
+		steps = append(steps, step{s.Step, s.State, detail})
+		rows = append(rows, []string{string(s.Step), string(s.State), detail})
+	}
+	if err := e.print(steps, []string{"STEP", "STATE", "DETAIL"}, rows); err != nil {
+		return err
+	}
+	return closeErr
+}
+
+func cardAction(e *env, name string, args []string, call func(ctx context.Context, cardId string) (juice.CardResp, error)) error {
+	pos, err := parse(flag.NewFlagSet(name, flag.ContinueOnError), args, "card-id")
+	if err != nil {
+		return err
+	}
+	card, err := call(e.ctx, pos[0])
+	if err != nil {
+		return err
+	}
+	return e.printCards(card, card)
+}
+
+func payment(e *env, name string, args []string, call func(ctx context.Context, data juice.PaymentData) (juice.CardResp, error)) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	var data juice.PaymentData
+	fs.StringVar(&data.Source, "source", "integrator", "funding source")
+	fs.StringVar(&data.IdempotencyKey, "idempotency-key", "", "key making a repeated payment safe")
+	pos, err := parse(fs, args, "card-id", "amount")
+	if err != nil {
+		return err
+	}
+	data.CardId = pos[0]
+	if data.Amount, err = parseAmount(pos[1]); err != nil {
+		return err
+	}
+
+	card, err := call(e.ctx, data)
+	if err != nil {
+		return err
+	}
+	return e.printCards(card, card)
+}
+
+var cardHeader = []string{"ID", "STATUS", "BALANCE", "TYPE", "VALID"}
+
+func (e *env) printAccount(a juice.Account) error {
+	return e.print(a, []string{"ID", "BUSINESS", "EMAIL", "DOMAIN", "USDC ADDRESS"},
+		[][]string{{a.Id, a.BusinessName, a.Email, a.Domain, a.UsdcAddress.Address}})
+}
+
+func (e *env) printUsers(v interface{}, users []juice.User) error {
+	var rows [][]string
+	for _, u := range users {
+		rows = append(rows, []string{u.Id, u.FirstName + " " + u.LastName, u.Email, u.PhoneNumber,
+			strconv.FormatBool(u.Verified), strconv.FormatBool(u.Archived)})
+	}
+	return e.print(v, []string{"ID", "NAME", "EMAIL", "PHONE", "VERIFIED", "ARCHIVED"}, rows)
+}
+
+func (e *env) printCards(v interface{}, cards ...juice.CardResp) error {
+	var rows [][]string
+	for _, c := range cards {
+		rows = append(rows, []string{c.Id, string(c.Status), c.Balance.String(), string(c.CardType), c.Valid})
+	}
+	return e.print(v, cardHeader, rows)
+}
+
+func (e *env) printTransactions(v interface{}, txs ...juice.Transaction) error {
+	var rows [][]string
+	for _, t := range txs {
+		narrative := ""
+		if t.Narrative != nil {
+			narrative = fmt.Sprint(t.Narrative)
+		}
+		rows = append(rows, []string{t.Id, t.CreatedAt.Format(time.RFC3339), string(t.Type), t.Amount.Decimal(),
+			string(t.Currency), t.CardBalanceAfter.Decimal(), narrative})
+	}
+	return e.print(v, []string{"ID", "CREATED", "TYPE", "AMOUNT", "CURRENCY", "BALANCE AFTER", "NARRATIVE"}, rows)
+}
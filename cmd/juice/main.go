@@ -0,0 +1,155 @@
+// Command juice calls the Spend-Juice card integrator API from the shell.
+//
+// It reads the API key from JUICE_PRIVATE_KEY, talks to production when ENV is
+// "production" and to the sandbox otherwise, and prints tables by default,
+// or JSON with -json:
+//
+//	juice cards freeze 0c7ca765-764c-4f62-9c35-ac3e2abcee01
+//	juice -json tx list 0c7ca765-764c-4f62-9c35-ac3e2abcee01
+//	juice cards credit 0c7ca765-764c-4f62-9c35-ac3e2abcee01 "12.50 USD"
+//
+// Amounts are decimal, optionally followed by a currency code; USD is assumed
+// when the code is left out.
+//
+// Run juice without arguments for the list of commands.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+
+	juice "github.com/bushaHQ/spend-juice-go"
+)
+
+// newClient builds the API client. Tests replace it to point at a fake server.
+var newClient = func() (*juice.Client, error) {
+	cl, err := juice.NewClient(juice.FromEnvironment())
+	if errors.Is(err, juice.ErrMissingAPIKey) {
+		return nil, fmt.Errorf("set JUICE_PRIVATE_KEY to your API key")
+	}
+	if err != nil {
+		return nil, err
+	}
+	cl.SetLogLevel(juice.LevelInfo)
+	return cl, nil
+}
+
+// env is what a command runs against.
+type env struct {
+	ctx  context.Context
+	cl   *juice.Client
+	out  io.Writer
+	json bool
+}
+
+// command is a "<group> <name>" subcommand.
+type command struct {
+	group, name string
+	args        string
+	help        string
+	endpoint    juice.Endpoint
+	run         func(e *env, args []string) error
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	global := flag.NewFlagSet("juice", flag.ContinueOnError)
+	global.SetOutput(stderr)
+	jsonOut := global.Bool("json", false, "print JSON instead of a table")
+	baseURL := global.String("base-url", "", "override the API base URL")
+	debug := global.Bool("debug", false, "log HTTP requests and responses, with card data redacted")
+	global.Usage = func() { usage(stderr, global) }
+
+	if err := global.Parse(args); err != nil {
+		return 2
+	}
+	args = global.Args()
+	if len(args) < 2 {
+		usage(stderr, global)
+		return 2
+	}
+
+	cmd := lookup(args[0], args[1])
+	if cmd == nil {
+		fmt.Fprintf(stderr, "juice: unknown command %q\n\n", strings.Join(args[:2], " "))
+		usage(stderr, global)
+		return 2
+	}
+
+	cl, err := newClient()
+	if err != nil {
+		fmt.Fprintf(stderr, "juice: %v\n", err)
+		return 1
+	}
+	if *baseURL != "" {
+		cl.SetBaseURL(*baseURL)
+	}
+	if *debug {
+		cl.SetLogLevel(juice.LevelDebug)
+	}
+
+	if !cl.Environment().Supports(cmd.endpoint) {
+		fmt.Fprintf(stderr, "juice %s %s: %v\n", cmd.group, cmd.name, juice.ErrSandboxOnly)
+		return 1
+	}
+
+	e := &env{ctx: ctx, cl: cl, out: stdout, json: *jsonOut}
+	if err := cmd.run(e, args[2:]); err != nil {
+		fmt.Fprintf(stderr, "juice %s %s: %v\n", cmd.group, cmd.name, err)
+		return 1
+	}
+	return 0
+}
+
+func lookup(group, name string) *command {
+	for i := range commands {
+		if commands[i].group == group && commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+func usage(w io.Writer, global *flag.FlagSet) {
+	fmt.Fprintf(w, "Usage: juice [flags] <command> [args]\n\nCommands:\n")
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, c := range commands {
+		help := c.help
+		if c.endpoint.SandboxOnly() {
+			help += " (sandbox only)"
+		}
+		fmt.Fprintf(tw, "  %s %s %s\t%s\n", c.group, c.name, c.args, help)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "\nFlags:\n")
+	global.PrintDefaults()
+}
+
+// print writes v as JSON, or as a table with the given header and rows.
+func (e *env) print(v interface{}, header []string, rows [][]string) error {
+	if e.json {
+		enc := json.NewEncoder(e.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+
+	tw := tabwriter.NewWriter(e.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	juice "github.com/bushaHQ/spend-juice-go"
+	"github.com/bushaHQ/spend-juice-go/juicetest"
+)
+
+func TestRun(t *testing.T) {
+	srv := juicetest.NewServer()
+	defer srv.Close()
+	srv.SetFloat(juice.USDCents(100000))
+	newClient = func() (*juice.Client, error) { return srv.Client(), nil }
+
+	cl := srv.Client()
+	user, err := cl.RegisterUser(juicetest.UserData("user1@gmail.com", "+2348023547672"), "integrator")
+	if err != nil {
+		t.Fatalf("RegisterUser() error = %v", err)
+	}
+	card, err := cl.CreateCard(juicetest.CardData(user.Data.Id))
+	if err != nil {
+		t.Fatalf("CreateCard() error = %v", err)
+	}
+	cardId := card.Data.Id
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantOut  []string
+	}{
+		{name: "float get", args: []string{"float", "get"}, wantOut: []string{"BALANCE", "1000.00", "USD"}},
+		{name: "credit", args: []string{"cards", "credit", cardId, "25.00"}, wantOut: []string{cardId, "active", "25.00 USD"}},
+		{name: "freeze", args: []string{"cards", "freeze", cardId}, wantOut: []string{cardId, "frozen"}},
+		{name: "tx list", args: []string{"tx", "list", "--limit", "1", cardId}, wantOut: []string{"credit", "25.00"}},
+		{name: "users list", args: []string{"users", "list"}, wantOut: []string{user.Data.Id, "user1@gmail.com"}},
+		{name: "users search", args: []string{"users", "search", "user1@gmail.com"}, wantOut: []string{user.Data.Id}},
+		{name: "users archive", args: []string{"users", "archive", user.Data.Id}, wantOut: []string{user.Data.Id, "true"}},
+		{name: "users update", args: []string{"users", "update", "--phone", "+2348023547699", user.Data.Id}, wantOut: []string{"+2348023547699"}},
+		{name: "users update invalid phone", args: []string{"users", "update", "--phone", "0802", user.Data.Id}, wantCode: 1},
+		{name: "json output", args: []string{"-json", "cards", "get", cardId}, wantOut: []string{`"status": "frozen"`}},
+		{name: "missing argument", args: []string{"cards", "freeze"}, wantCode: 1},
+		{name: "bad amount", args: []string{"cards", "debit", cardId, "ten"}, wantCode: 1},
+		{name: "api error", args: []string{"cards", "get", "missing"}, wantCode: 1},
+		{name: "unknown command", args: []string{"cards", "shred", cardId}, wantCode: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			code := run(context.Background(), tt.args, &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Fatalf("run() = %d, want %d; stderr: %s", code, tt.wantCode, stderr.String())
+			}
+			for _, want := range tt.wantOut {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("run() output missing %q:\n%s", want, stdout.String())
+				}
+			}
+		})
+	}
+
+	var stdout bytes.Buffer
+	run(context.Background(), []string{"-json", "float", "get"}, &stdout, &bytes.Buffer{})
+	var float juice.BalanceResp
+	if err := json.Unmarshal(stdout.Bytes(), &float); err != nil || float.Balance != juice.USDCents(97500) {
+		t.Errorf("float after credit = %+v (%v), want balance 975.00 USD", float, err)
+	}
+}
+
+func TestRun_sandboxOnly(t *testing.T) {
+	srv := juicetest.NewServer()
+	defer srv.Close()
+	srv.Environment = juice.Production
+	newClient = func() (*juice.Client, error) { return srv.Client(), nil }
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"float", "topup", "5000"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("run() = %d, want 1", code)
+	}
+	if !strings.Contains(stderr.String(), "only available in the sandbox") {
+		t.Errorf("stderr = %q, want it to explain the command is sandbox only", stderr.String())
+	}
+	if got := srv.Float(); !got.IsZero() {
+		t.Errorf("float = %v after a refused top-up", got)
+	}
+
+	stderr.Reset()
+	run(context.Background(), nil, &stdout, &stderr)
+	if !strings.Contains(stderr.String(), "top up the float (sandbox only)") {
+		t.Errorf("usage does not flag sandbox-only commands:\n%s", stderr.String())
+	}
+}
+
+func TestRun_closeCard(t *testing.T) {
+	srv := juicetest.NewServer()
+	defer srv.Close()
+	srv.SetFloat(juice.USDCents(100000))
+	newClient = func() (*juice.Client, error) { return srv.Client(), nil }
+
+	cl := srv.Client()
+	user, err := cl.RegisterUser(juicetest.UserData("user1@gmail.com", "+2348023547672"), "integrator")
+	if err != nil {
+		t.Fatalf("RegisterUser() error = %v", err)
+	}
+	card, err := cl.CreateCard(juicetest.CardData(user.Data.Id))
+	if err != nil {
+		t.Fatalf("CreateCard() error = %v", err)
+	}
+	if _, err := cl.CreditCard(juice.PaymentData{Source: "integrator", Amount: juice.USDCents(2500), CardId: card.Data.Id}); err != nil {
+		t.Fatalf("CreditCard() error = %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"cards", "terminate", card.Data.Id}, &stdout, &stderr); code != 1 {
+		t.Errorf("terminate with a balance = %d, want 1", code)
+	}
+	stdout.Reset()
+	if code := run(context.Background(), []string{"cards", "close", card.Data.Id}, &stdout, &stderr); code != 0 {
+		t.Fatalf("run() = %d, want 0; stderr: %s", code, stderr.String())
+	}
+	for _, want := range []string{"freeze", "sweep", "25.00 USD to float", "terminate", "done"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("close output missing %q:\n%s", want, stdout.String())
+		}
+	}
+	if c, _ := srv.Card(card.Data.Id); c.Status != juice.CardTerminated {
+		t.Errorf("card status = %s, want terminated", c.Status)
+	}
+}
@@ -0,0 +1,198 @@
+package juice
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// The enumerated fields below decode known values case-insensitively into
+// their constants and keep values this package doesn't know yet as sent, so a
+// new status or type from the API never fails a response. Known reports
+// whether a value is one of the constants, for switches that must be
+// exhaustive.
+
+// CardType is the kind of card issued.
+type CardType string
+
+const (
+	CardTypeVirtual  CardType = "virtual"
+	CardTypePhysical CardType = "physical"
+)
+
+var cardTypes = []string{string(CardTypeVirtual), string(CardTypePhysical)}
+
+// Known reports whether t is one of the CardType constants.
+func (t CardType) Known() bool { return known(string(t), cardTypes) }
+
+// MarshalJSON encodes t as a JSON string.
+func (t CardType) MarshalJSON() ([]byte, error) { return json.Marshal(string(t)) }
+
+// UnmarshalJSON decodes a JSON string into t.
+func (t *CardType) UnmarshalJSON(data []byte) error {
+	s, err := decodeEnum(data, "card type", cardTypes)
+	*t = CardType(s)
+	return err
+}
+
+// CardStatus is the state of a card.
+type CardStatus string
+
+const (
+	CardActive   CardStatus = "active"
+	CardInactive CardStatus = "inactive"
+	CardFrozen   CardStatus = "frozen"
+	// CardTerminated is permanent: a terminated card can't be used or
+	// reactivated.
+	CardTerminated CardStatus = "terminated"
+)
+
+var cardStatuses = []string{string(CardActive), string(CardInactive), string(CardFrozen), string(CardTerminated)}
+
+// Known reports whether s is one of the CardStatus constants.
+func (s CardStatus) Known() bool { return known(string(s), cardStatuses) }
+
+// MarshalJSON encodes s as a JSON string.
+func (s CardStatus) MarshalJSON() ([]byte, error) { return json.Marshal(string(s)) }
+
+// UnmarshalJSON decodes a JSON string into s.
+func (s *CardStatus) UnmarshalJSON(data []byte) error {
+	v, err := decodeEnum(data, "card status", cardStatuses)
+	*s = CardStatus(v)
+	return err
+}
+
+// DesignType is the artwork printed on a card.
+type DesignType string
+
+const (
+	DesignAurora DesignType = "Aurora"
+)
+
+var designTypes = []string{string(DesignAurora)}
+
+// Known reports whether d is one of the DesignType constants.
+func (d DesignType) Known() bool { return known(string(d), designTypes) }
+
+// MarshalJSON encodes d as a JSON string.
+func (d DesignType) MarshalJSON() ([]byte, error) { return json.Marshal(string(d)) }
+
+// UnmarshalJSON decodes a JSON string into d.
+func (d *DesignType) UnmarshalJSON(data []byte) error {
+	s, err := decodeEnum(data, "design type", designTypes)
+	*d = DesignType(s)
+	return err
+}
+
+// TransactionType is what a card transaction did. It is also the kind of
+// transaction MockTransaction simulates.
+type TransactionType string
+
+const (
+	// TransactionCredit adds money to the card, from the float or as a refund.
+	TransactionCredit TransactionType = "credit"
+	// TransactionDebit takes money from the card, to the float or to a merchant.
+	TransactionDebit TransactionType = "debit"
+	// TransactionDeduct is a merchant deduction.
+	TransactionDeduct TransactionType = "deduct"
+	// TransactionDeductReversal returns a merchant deduction to the card.
+	TransactionDeductReversal TransactionType = "deduct-reversal"
+)
+
+var transactionTypes = []string{
+	string(TransactionCredit), string(TransactionDebit), string(TransactionDeduct), string(TransactionDeductReversal),
+}
+
+// Known reports whether t is one of the TransactionType constants.
+func (t TransactionType) Known() bool { return known(string(t), transactionTypes) }
+
+// Outgoing reports whether t takes money from the card. It is false for
+// unknown types.
+func (t TransactionType) Outgoing() bool {
+	return t == TransactionDebit || t == TransactionDeduct
+}
+
+// MarshalJSON encodes t as a JSON string.
+func (t TransactionType) MarshalJSON() ([]byte, error) { return json.Marshal(string(t)) }
+
+// UnmarshalJSON decodes a JSON string into t.
+func (t *TransactionType) UnmarshalJSON(data []byte) error {
+	s, err := decodeEnum(data, "transaction type", transactionTypes)
+	*t = TransactionType(s)
+	return err
+}
+
+// IdType is the identity document a card user registers with.
+type IdType string
+
+const (
+	IdBVN      IdType = "BVN"
+	IdNIN      IdType = "NIN"
+	IdPassport IdType = "PASSPORT"
+)
+
+var idTypes = []string{string(IdBVN), string(IdNIN), string(IdPassport)}
+
+// Known reports whether t is one of the IdType constants.
+func (t IdType) Known() bool { return known(string(t), idTypes) }
+
+// MarshalJSON encodes t as a JSON string.
+func (t IdType) MarshalJSON() ([]byte, error) { return json.Marshal(string(t)) }
+
+// UnmarshalJSON decodes a JSON string into t.
+func (t *IdType) UnmarshalJSON(data []byte) error {
+	s, err := decodeEnum(data, "id type", idTypes)
+	*t = IdType(s)
+	return err
+}
+
+// Chain is the blockchain an integrator's USDC float address is on.
+type Chain string
+
+const (
+	ChainETH  Chain = "ETH"
+	ChainTRON Chain = "TRON"
+	ChainSOL  Chain = "SOL"
+)
+
+var chains = []string{string(ChainETH), string(ChainTRON), string(ChainSOL)}
+
+// Known reports whether c is one of the Chain constants.
+func (c Chain) Known() bool { return known(string(c), chains) }
+
+// MarshalJSON encodes c as a JSON string.
+func (c Chain) MarshalJSON() ([]byte, error) { return json.Marshal(string(c)) }
+
+// UnmarshalJSON decodes a JSON string into c.
+func (c *Chain) UnmarshalJSON(data []byte) error {
+	s, err := decodeEnum(data, "chain", chains)
+	*c = Chain(s)
+	return err
+}
+
+func known(s string, values []string) bool {
+	for _, v := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeEnum decodes a JSON string, returning the matching known value when
+// there is one and the string as sent otherwise. null decodes to "".
+func decodeEnum(data []byte, name string, values []string) (string, error) {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return "", fmt.Errorf("juice: %s must be a string: %w", name, err)
+	}
+	if s == nil {
+		return "", nil
+	}
+	for _, v := range values {
+		if strings.EqualFold(*s, v) {
+			return v, nil
+		}
+	}
+	return *s, nil
+}
@@ -0,0 +1,78 @@
+package juice
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestEnums_UnmarshalJSON(t *testing.T) {
+	var card Card
+	body := `{"card_type":"VIRTUAL","status":"Frozen","design_type":"aurora","currency":"usd","balance":100}`
+	if err := json.Unmarshal([]byte(body), &card); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if card.CardType != CardTypeVirtual || card.Status != CardFrozen || card.DesignType != DesignAurora || card.Currency != USD {
+		t.Errorf("known values decoded as %q %q %q %q", card.CardType, card.Status, card.DesignType, card.Currency)
+	}
+	if card.Balance != USDCents(100) {
+		t.Errorf("Balance = %v, want 1.00 USD", card.Balance)
+	}
+
+	tests := []struct {
+		name      string
+		body      string
+		want      interface{}
+		wantKnown bool
+		wantErr   bool
+	}{
+		{name: "known status", body: `{"status":"active"}`, want: CardActive, wantKnown: true},
+		{name: "unknown status kept", body: `{"status":"Suspended"}`, want: CardStatus("Suspended")},
+		{name: "null status", body: `{"status":null}`, want: CardStatus("")},
+		{name: "numeric status", body: `{"status":1}`, wantErr: true},
+		{name: "known type", body: `{"type":"Deduct-Reversal"}`, want: TransactionDeductReversal, wantKnown: true},
+		{name: "unknown type kept", body: `{"type":"fee"}`, want: TransactionType("fee")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v struct {
+				Status CardStatus      `json:"status"`
+				Type   TransactionType `json:"type"`
+			}
+			err := json.Unmarshal([]byte(tt.body), &v)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var got interface{} = v.Status
+			known := v.Status.Known()
+			if _, ok := tt.want.(TransactionType); ok {
+				got, known = v.Type, v.Type.Known()
+			}
+			if !reflect.DeepEqual(got, tt.want) || known != tt.wantKnown {
+				t.Errorf("decoded %q (known %v), want %q (known %v)", got, known, tt.want, tt.wantKnown)
+			}
+		})
+	}
+}
+
+func TestEnums_MarshalJSON(t *testing.T) {
+	data := RegisterAccountData{Chain: ChainETH, FloatCurrencies: []Currency{USD, "usdc"}}
+	b, err := json.Marshal(MockTransactionData{Amount: USDCents(100), Type: TransactionDebit})
+	if err != nil || string(b) != `{"amount":100,"type":"debit"}` {
+		t.Errorf("Marshal(MockTransactionData) = %s, %v", b, err)
+	}
+	b, err = json.Marshal(data)
+	if err != nil {
+		t.Fatalf("Marshal(RegisterAccountData) error = %v", err)
+	}
+	var back RegisterAccountData
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if back.Chain != ChainETH || !reflect.DeepEqual(back.FloatCurrencies, []Currency{USD, "USDC"}) {
+		t.Errorf("round trip = %+v", back)
+	}
+}
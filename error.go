@@ -1,20 +1,172 @@
 package juice
 
 import (
+	er "errors"
 	"fmt"
+	"net/http"
+	"sort"
 	"strings"
 )
 
-func (e Error) Error() string {
+// requestIdHeader carries the ID Spend-Juice assigns to each request.
+const requestIdHeader = "X-Request-Id"
+
+// APIError is returned, as a value, for responses with a non-2xx status. Use
+// errors.As with an APIError or *APIError target to get at it, or the
+// IsNotFound family of helpers to branch on the common cases.
+type APIError struct {
+	// StatusCode is the HTTP status of the response.
+	StatusCode int `json:"-"`
+	// RequestId is the X-Request-Id response header, if any. Quote it when
+	// contacting Spend-Juice support.
+	RequestId string `json:"-"`
+	// Method and Endpoint identify the call that failed, e.g. "PATCH" and
+	// "/cards/credit/balance".
+	Method   string `json:"-"`
+	Endpoint string `json:"-"`
+	// ContentType is the Content-Type of the response.
+	ContentType string `json:"-"`
+	// Body holds the start of the response body when it was not a JSON error
+	// object, such as a gateway's HTML error page.
+	Body string `json:"-"`
+
+	Message string `json:"message"`
+	// Errors is the raw "errors" member of the response body.
+	Errors interface{} `json:"errors"`
+	// Fields lists the per-field validation errors parsed from Errors.
+	Fields []FieldError `json:"-"`
+}
+
+// Error is the former name of APIError.
+type Error = APIError
+
+// As lets errors.As fill an *APIError target as well as an APIError one.
+func (e APIError) As(target interface{}) bool {
+	if t, ok := target.(**APIError); ok {
+		*t = &e
+		return true
+	}
+	return false
+}
+
+// FieldError is a validation error for one request field.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e APIError) Error() string {
 	errorBuilder := strings.Builder{}
 	errorBuilder.WriteString(e.Message + " ")
-	if e.Errors != nil {
+	if len(e.Fields) > 0 {
+		for _, f := range e.Fields {
+			errorBuilder.WriteString(fmt.Sprintf("(%s), %s; ", f.Field, strings.TrimRight(f.Message, ".")))
+		}
+	} else if e.Body != "" {
+		errorBuilder.WriteString(fmt.Sprintf("(status %d); ", e.StatusCode))
+	} else if _, parsed := e.Errors.(map[string]interface{}); e.Errors != nil && !parsed {
 		errorBuilder.WriteString(fmt.Sprintf("%v", e.Errors) + "; ")
 	}
-	return strings.ToLower(strings.Trim(errorBuilder.String(), ";. "))
+	msg := strings.ToLower(strings.Trim(errorBuilder.String(), ";. "))
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// DecodeError is returned when a successful response can't be decoded into
+// the result type.
+type DecodeError struct {
+	StatusCode  int
+	Method      string
+	Endpoint    string
+	ContentType string
+	// Body holds the start of the response body.
+	Body string
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("juice: decoding %s %s response (status %d, %s): %v", e.Method, e.Endpoint, e.StatusCode, e.ContentType, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// maxErrorBody caps how much of an error response is read, and maxBodySnippet
+// how much of it is kept on the error.
+const (
+	maxErrorBody   = 64 << 10
+	maxBodySnippet = 512
+)
+
+// truncate returns body as a single line of at most maxBodySnippet bytes.
+func truncate(body []byte) string {
+	s := strings.Join(strings.Fields(string(body)), " ")
+	if len(s) > maxBodySnippet {
+		s = strings.ToValidUTF8(s[:maxBodySnippet], "") + "..."
+	}
+	return s
+}
+
+// parse fills in Message and Fields from the decoded body. Spend-Juice sends
+// field errors as {"errors": {"field": ["message", ...]}} and some errors as
+// {"errors": {"message": "..."}} without a top-level message.
+func (e *APIError) parse() {
+	errs, ok := e.Errors.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	fields := make([]string, 0, len(errs))
+	for field := range errs {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		switch v := errs[field].(type) {
+		case string:
+			if field == "message" && e.Message == "" {
+				e.Message = v
+				continue
+			}
+			e.Fields = append(e.Fields, FieldError{Field: field, Message: v})
+		case []interface{}:
+			for _, m := range v {
+				if s, ok := m.(string); ok {
+					e.Fields = append(e.Fields, FieldError{Field: field, Message: s})
+				}
+			}
+		}
+	}
+}
+
+// IsNotFound reports whether err is an APIError with status 404.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsUnauthorized reports whether err is an APIError with status 401 or 403.
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized) || hasStatus(err, http.StatusForbidden)
+}
+
+// IsRateLimited reports whether err is an APIError with status 429 or the
+// client's own ErrRateLimited.
+func IsRateLimited(err error) bool {
+	return er.Is(err, ErrRateLimited) || hasStatus(err, http.StatusTooManyRequests)
+}
+
+// IsInsufficientFunds reports whether err is an APIError with status 402,
+// which Spend-Juice sends when the float or card balance is too low for a
+// credit or debit.
+func IsInsufficientFunds(err error) bool {
+	return hasStatus(err, http.StatusPaymentRequired)
 }
 
-type Error struct {
-	Errors  interface{} `json:"errors"`
-	Message string      `json:"message"`
+func hasStatus(err error, status int) bool {
+	var e *APIError
+	return er.As(err, &e) && e.StatusCode == status
 }
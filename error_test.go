@@ -0,0 +1,228 @@
+package juice
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestClient_apiErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		call   func(c *Client) error
+		want   APIError
+		is     func(error) bool
+	}{
+		{
+			name:   "field errors",
+			status: 422,
+			body:   `{"errors":{"amount":["This field must be at least 500000."],"currency":["This field is required."]},"message":"Unprocessable entity"}`,
+			call: func(c *Client) error {
+				_, err := c.TopUpFloat(USDCents(1))
+				return err
+			},
+			want: APIError{
+				StatusCode: 422,
+				RequestId:  "req-1",
+				Method:     http.MethodPatch,
+				Endpoint:   "/card-integrators/top-up-float",
+				Message:    "Unprocessable entity",
+				Fields: []FieldError{
+					{Field: "amount", Message: "This field must be at least 500000."},
+					{Field: "currency", Message: "This field is required."},
+				},
+			},
+		},
+		{
+			name:   "not found",
+			status: 404,
+			body:   `{"message":"Card not found"}`,
+			call: func(c *Client) error {
+				_, err := c.GetCard("missing")
+				return err
+			},
+			want: APIError{StatusCode: 404, RequestId: "req-1", Method: http.MethodGet, Endpoint: "/cards/missing", Message: "Card not found"},
+			is:   IsNotFound,
+		},
+		{
+			name:   "insufficient funds",
+			status: 402,
+			body:   `{"message":"Insufficient float balance"}`,
+			call: func(c *Client) error {
+				_, err := c.CreditCard(PaymentData{Source: "integrator", Amount: USDCents(100), CardId: "card", IdempotencyKey: "k"})
+				return err
+			},
+			want: APIError{StatusCode: 402, RequestId: "req-1", Method: http.MethodPatch, Endpoint: "/cards/credit/balance", Message: "Insufficient float balance"},
+			is:   IsInsufficientFunds,
+		},
+		{
+			name:   "rate limited",
+			status: 429,
+			body:   `{"message":"Too many requests"}`,
+			call: func(c *Client) error {
+				_, err := c.GetFloat()
+				return err
+			},
+			want: APIError{StatusCode: 429, RequestId: "req-1", Method: http.MethodGet, Endpoint: "/card-integrators/float", Message: "Too many requests"},
+			is:   IsRateLimited,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient()
+			c.SetRetryPolicy(NoRetries)
+			c.SetHTTPClient(&MockHttpClient{
+				DoFunc: func(r *http.Request) (*http.Response, error) {
+					return &http.Response{
+						StatusCode: tt.status,
+						Header:     http.Header{"X-Request-Id": {"req-1"}},
+						Body:       ioutil.NopCloser(bytes.NewReader([]byte(tt.body))),
+					}, nil
+				},
+			})
+
+			err := tt.call(c)
+			var got *APIError
+			if !errors.As(err, &got) {
+				t.Fatalf("error = %#v, want an *APIError", err)
+			}
+			got.Errors = nil
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("error = %#v, want %#v", *got, tt.want)
+			}
+			if tt.is != nil && !tt.is(err) {
+				t.Errorf("error %v not matched by its Is helper", err)
+			}
+		})
+	}
+}
+
+func TestAPIError_forms(t *testing.T) {
+	c := newTestClient()
+	c.SetRetryPolicy(NoRetries)
+	c.SetHTTPClient(&MockHttpClient{
+		DoFunc: func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: 404,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"message":"Card not found"}`))),
+			}, nil
+		},
+	})
+	_, err := c.GetCard("missing")
+
+	if e, ok := err.(Error); !ok || e.StatusCode != 404 {
+		t.Errorf("err.(Error) = %#v, %v, want the 404 value", e, ok)
+	}
+	var value Error
+	if !errors.As(err, &value) || value.Message != "Card not found" {
+		t.Errorf("errors.As(err, &Error{}) = %#v, want the 404 error", value)
+	}
+	var ptr *APIError
+	if !errors.As(err, &ptr) || ptr.StatusCode != 404 {
+		t.Errorf("errors.As(err, &*APIError) = %#v, want the 404 error", ptr)
+	}
+	wrapped := fmt.Errorf("freezing: %w", err)
+	if !errors.As(wrapped, &ptr) || !IsNotFound(wrapped) {
+		t.Errorf("errors.As on a wrapped error = %#v, want the 404 error", ptr)
+	}
+}
+
+func TestIsHelpers(t *testing.T) {
+	tests := []struct {
+		name string
+		is   func(error) bool
+		err  error
+		want bool
+	}{
+		{"insufficient funds on 402", IsInsufficientFunds, APIError{StatusCode: 402, Message: "Card balance too low"}, true},
+		{"insufficient in a 400 message", IsInsufficientFunds, APIError{StatusCode: 400, Message: "Insufficient detail in request"}, false},
+		{"rate limited on 429", IsRateLimited, APIError{StatusCode: 429}, true},
+		{"client-side rate limit", IsRateLimited, ErrRateLimited, true},
+		{"wrapped client-side rate limit", IsRateLimited, fmt.Errorf("listing: %w", ErrRateLimited), true},
+		{"other error", IsRateLimited, errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.is(tt.err); got != tt.want {
+				t.Errorf("helper(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClient_nonJSONResponses(t *testing.T) {
+	respond := func(status int, contentType, body string) *Client {
+		c := newTestClient()
+		c.SetRetryPolicy(NoRetries)
+		c.SetHTTPClient(&MockHttpClient{
+			DoFunc: func(r *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: status,
+					Header:     http.Header{"Content-Type": {contentType}},
+					Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+				}, nil
+			},
+		})
+		return c
+	}
+
+	t.Run("HTML gateway error keeps status and body", func(t *testing.T) {
+		_, err := respond(502, "text/html", "<html>\n<body>Bad Gateway</body>\n</html>").GetFloat()
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("error = %#v, want an *APIError", err)
+		}
+		if apiErr.StatusCode != 502 || apiErr.ContentType != "text/html" || apiErr.Body != "<html> <body>Bad Gateway</body> </html>" {
+			t.Errorf("error = %#v", apiErr)
+		}
+		if want := "bad gateway (status 502): <html> <body>Bad Gateway</body> </html>"; err.Error() != want {
+			t.Errorf("Error() = %q, want %q", err.Error(), want)
+		}
+	})
+
+	t.Run("long bodies are truncated", func(t *testing.T) {
+		_, err := respond(500, "text/plain", string(bytes.Repeat([]byte("x"), 4096))).GetFloat()
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || len(apiErr.Body) != maxBodySnippet+len("...") {
+			t.Errorf("error = %#v, want a body truncated to %d bytes", err, maxBodySnippet)
+		}
+	})
+
+	t.Run("empty error body", func(t *testing.T) {
+		_, err := respond(503, "", "").GetFloat()
+		if want := "service unavailable"; err == nil || err.Error() != want {
+			t.Errorf("error = %v, want %q", err, want)
+		}
+	})
+
+	t.Run("204 with no body succeeds", func(t *testing.T) {
+		if _, err := respond(204, "", "").FreezeCard("card"); err != nil {
+			t.Errorf("FreezeCard() error = %v", err)
+		}
+	})
+
+	t.Run("empty 200 is a decode error", func(t *testing.T) {
+		_, err := respond(200, "application/json", "").GetCard("card")
+		var decErr *DecodeError
+		if !errors.As(err, &decErr) || decErr.StatusCode != 200 {
+			t.Errorf("error = %#v, want a *DecodeError", err)
+		}
+	})
+
+	t.Run("undecodable success body", func(t *testing.T) {
+		_, err := respond(200, "text/html", "<html>OK</html>").GetFloat()
+		var decErr *DecodeError
+		if !errors.As(err, &decErr) {
+			t.Fatalf("error = %#v, want a *DecodeError", err)
+		}
+		if decErr.StatusCode != 200 || decErr.Body != "<html>OK</html>" || decErr.Endpoint != "/card-integrators/float" {
+			t.Errorf("error = %#v", decErr)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package export
+
+import (
+	"fmt"
+	"time"
+
+	juice "github.com/bushaHQ/spend-juice-go"
+)
+
+// Column is a CSV column or JSON Lines field.
+type Column struct {
+	Name  string
+	Value func(r Record, amounts AmountFormat) string
+	// Number marks amount and balance columns. JSON Lines writes them as
+	// numbers unless amounts are formatted WithCurrency.
+	Number bool
+}
+
+var (
+	ColumnId = Column{Name: "id", Value: func(r Record, _ AmountFormat) string { return r.Id }}
+	// ColumnDate is the creation time in RFC 3339, UTC.
+	ColumnDate = Column{Name: "created_at", Value: func(r Record, _ AmountFormat) string {
+		return r.CreatedAt.UTC().Format(time.RFC3339)
+	}}
+	ColumnType = Column{Name: "type", Value: func(r Record, _ AmountFormat) string { return string(r.Type) }}
+	// ColumnAmount is the amount as it is reported by the API, always positive.
+	ColumnAmount = amountColumn("amount", func(r Record) juice.Money { return r.Amount })
+	// ColumnSignedAmount is negative for money leaving the card.
+	ColumnSignedAmount  = amountColumn("signed_amount", Record.Signed)
+	ColumnCurrency      = Column{Name: "currency", Value: func(r Record, _ AmountFormat) string { return string(r.Amount.Currency) }}
+	ColumnBalanceBefore = amountColumn("balance_before", func(r Record) juice.Money { return r.CardBalanceBefore })
+	ColumnBalanceAfter  = amountColumn("balance_after", func(r Record) juice.Money { return r.CardBalanceAfter })
+	ColumnNarrative     = Column{Name: "narrative", Value: func(r Record, _ AmountFormat) string { return narrative(r) }}
+	ColumnCard          = Column{Name: "card_id", Value: func(r Record, _ AmountFormat) string { return r.CardId }}
+	ColumnUser          = Column{Name: "user_id", Value: func(r Record, _ AmountFormat) string { return r.UserId }}
+)
+
+// amountColumn is a Number column formatting the amount money picks out.
+func amountColumn(name string, money func(Record) juice.Money) Column {
+	return Column{Name: name, Value: func(r Record, f AmountFormat) string { return f.Format(money(r)) }, Number: true}
+}
+
+// DefaultColumns are exported when Options.Columns is empty.
+var DefaultColumns = []Column{
+	ColumnDate, ColumnId, ColumnCard, ColumnType, ColumnSignedAmount, ColumnCurrency, ColumnBalanceAfter, ColumnNarrative,
+}
+
+func (o Options) columns() []Column {
+	if len(o.Columns) == 0 {
+		return DefaultColumns
+	}
+	return o.Columns
+}
+
+func narrative(r Record) string {
+	if r.Narrative == nil {
+		return ""
+	}
+	return fmt.Sprint(r.Narrative)
+}
@@ -0,0 +1,62 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strings"
+)
+
+type csvEncoder struct {
+	w       *csv.Writer
+	columns []Column
+	amounts AmountFormat
+	started bool
+}
+
+func newCSVEncoder(w io.Writer, opts Options) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w), columns: opts.columns(), amounts: opts.Amounts}
+}
+
+func (e *csvEncoder) header() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	names := make([]string, len(e.columns))
+	for i, c := range e.columns {
+		names[i] = c.Name
+	}
+	return e.w.Write(names)
+}
+
+func (e *csvEncoder) write(r Record) error {
+	if err := e.header(); err != nil {
+		return err
+	}
+	row := make([]string, len(e.columns))
+	for i, c := range e.columns {
+		row[i] = c.Value(r, e.amounts)
+		if !c.Number {
+			row[i] = defuse(row[i])
+		}
+	}
+	return e.w.Write(row)
+}
+
+// defuse prefixes a cell that a spreadsheet would read as a formula with a
+// quote, so narratives written by merchants can't run in the reader's
+// spreadsheet. Number columns are left alone to keep negative amounts.
+func defuse(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+func (e *csvEncoder) close() error {
+	if err := e.header(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
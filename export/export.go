@@ -0,0 +1,178 @@
+// Package export writes card transactions out as statements in CSV, JSON
+// Lines or OFX 2.x.
+//
+// Transactions are streamed from the API page by page, newest first, and
+// paging stops at the From cutoff. CSV and JSON Lines rows are written as they
+// arrive. OFX holds one card's transactions at a time, since a statement's
+// header needs their date range, so exporting the whole integrator never holds
+// more than its busiest card:
+//
+//	f, _ := os.Create("statement.csv")
+//	defer f.Close()
+//	err := export.Card(ctx, cl, cardId, f, export.Options{
+//		Format: export.CSV,
+//		From:   time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+//		To:     time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC),
+//	})
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	juice "github.com/bushaHQ/spend-juice-go"
+)
+
+// Format is an output file format.
+type Format string
+
+const (
+	CSV       Format = "csv"
+	JSONLines Format = "jsonl"
+	OFX       Format = "ofx"
+)
+
+// AmountFormat controls how amounts are written in CSV and JSON Lines. OFX
+// always uses signed decimals.
+type AmountFormat int
+
+const (
+	// Decimal writes amounts in major units, e.g. "12.50".
+	Decimal AmountFormat = iota
+	// MinorUnits writes amounts in minor units, e.g. "1250".
+	MinorUnits
+	// WithCurrency writes amounts in major units followed by the currency,
+	// e.g. "12.50 USD".
+	WithCurrency
+)
+
+// Format formats m.
+func (f AmountFormat) Format(m juice.Money) string {
+	switch f {
+	case MinorUnits:
+		return fmt.Sprint(m.Amount)
+	case WithCurrency:
+		return m.String()
+	}
+	return m.Decimal()
+}
+
+// Options configures an export.
+type Options struct {
+	Format Format
+	// Columns lists the CSV and JSON Lines fields in order. DefaultColumns
+	// are used when it is empty.
+	Columns []Column
+	// From and To limit the export to transactions created at or after From
+	// and before To. Zero values leave the range open.
+	From, To time.Time
+	Amounts  AmountFormat
+	// PageSize is how many items are fetched per request.
+	PageSize int
+}
+
+// Record is a transaction together with the card and user it belongs to.
+type Record struct {
+	CardId string
+	UserId string
+	juice.Transaction
+}
+
+// Signed returns the amount as it affected the card balance: negative for
+// money leaving the card and positive for money coming in.
+func (r Record) Signed() juice.Money {
+	switch {
+	case r.CardBalanceAfter.Amount < r.CardBalanceBefore.Amount:
+		return r.Amount.Neg()
+	case r.CardBalanceAfter.Amount > r.CardBalanceBefore.Amount:
+		return r.Amount
+	}
+	if r.Type.Outgoing() {
+		return r.Amount.Neg()
+	}
+	return r.Amount
+}
+
+// Card exports the transactions of one card. The records' UserId is left
+// empty since a card lookup doesn't report its user.
+func Card(ctx context.Context, cl *juice.Client, cardId string, w io.Writer, opts Options) error {
+	return run(w, opts, func(emit func(Record) error) error {
+		return cardRecords(ctx, cl, cardId, "", opts, emit)
+	})
+}
+
+// User exports the transactions of every card a user holds.
+func User(ctx context.Context, cl *juice.Client, userId string, w io.Writer, opts Options) error {
+	return run(w, opts, func(emit func(Record) error) error {
+		return userRecords(ctx, cl, userId, opts, emit)
+	})
+}
+
+// Integrator exports the transactions of every card of every user.
+func Integrator(ctx context.Context, cl *juice.Client, w io.Writer, opts Options) error {
+	return run(w, opts, func(emit func(Record) error) error {
+		users := cl.Users(ctx, opts.PageSize)
+		for users.Next() {
+			if err := userRecords(ctx, cl, users.User().Id, opts, emit); err != nil {
+				return err
+			}
+		}
+		return users.Err()
+	})
+}
+
+func userRecords(ctx context.Context, cl *juice.Client, userId string, opts Options, emit func(Record) error) error {
+	cards := cl.Cards(ctx, userId, opts.PageSize)
+	for cards.Next() {
+		if err := cardRecords(ctx, cl, cards.Card().Id, userId, opts, emit); err != nil {
+			return err
+		}
+	}
+	return cards.Err()
+}
+
+func cardRecords(ctx context.Context, cl *juice.Client, cardId, userId string, opts Options, emit func(Record) error) error {
+	txs := cl.Transactions(ctx, cardId, opts.PageSize)
+	for txs.Next() {
+		t := txs.Transaction()
+		// The API lists transactions newest first, so the rest are older.
+		if !opts.From.IsZero() && t.CreatedAt.Before(opts.From) {
+			break
+		}
+		if !opts.To.IsZero() && !t.CreatedAt.Before(opts.To) {
+			continue
+		}
+		if err := emit(Record{CardId: cardId, UserId: userId, Transaction: t}); err != nil {
+			return err
+		}
+	}
+	return txs.Err()
+}
+
+// encoder writes records in one format. Records arrive grouped by card.
+type encoder interface {
+	write(r Record) error
+	close() error
+}
+
+// run streams the records produced by source to w.
+func run(w io.Writer, opts Options, source func(emit func(Record) error) error) error {
+	var enc encoder
+	switch opts.Format {
+	case CSV, "":
+		enc = newCSVEncoder(w, opts)
+	case JSONLines:
+		enc = newJSONLinesEncoder(w, opts)
+	case OFX:
+		enc = newOFXEncoder(w, opts)
+	default:
+		return fmt.Errorf("export: unknown format %q", opts.Format)
+	}
+
+	if err := source(enc.write); err != nil {
+		return err
+	}
+	return enc.close()
+}
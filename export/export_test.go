@@ -0,0 +1,223 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	juice "github.com/bushaHQ/spend-juice-go"
+	"github.com/bushaHQ/spend-juice-go/juicetest"
+)
+
+// fixture creates two users with one card each on a fake server whose clock
+// reads 2023-06-01 09:00 UTC first and moves an hour on every read.
+func fixture(t *testing.T) (*juicetest.Server, *juice.Client, []string) {
+	srv := juicetest.NewServer()
+	t.Cleanup(srv.Close)
+	now := time.Date(2023, 6, 1, 8, 0, 0, 0, time.UTC)
+	srv.Now = func() time.Time {
+		now = now.Add(time.Hour)
+		return now
+	}
+	srv.SetFloat(juice.USDCents(1000000))
+	cl := srv.Client()
+
+	var cards []string
+	for i, email := range []string{"user1@gmail.com", "user2@gmail.com"} {
+		user, err := cl.RegisterUser(juicetest.UserData(email, "+23480235476"+string(rune('0'+i))), "integrator")
+		if err != nil {
+			t.Fatalf("RegisterUser() error = %v", err)
+		}
+		card, err := cl.CreateCard(juicetest.CardData(user.Data.Id))
+		if err != nil {
+			t.Fatalf("CreateCard() error = %v", err)
+		}
+		cards = append(cards, card.Data.Id)
+	}
+	if _, err := cl.CreditCard(juice.PaymentData{Source: "integrator", Amount: juice.USDCents(5000), CardId: cards[0]}); err != nil {
+		t.Fatalf("CreditCard() error = %v", err)
+	}
+	if _, err := cl.MockTransaction(juice.MockTransactionData{Amount: juice.USDCents(1250), Type: "debit"}, cards[0]); err != nil {
+		t.Fatalf("MockTransaction() error = %v", err)
+	}
+	if _, err := cl.CreditCard(juice.PaymentData{Source: "integrator", Amount: juice.USDCents(700), CardId: cards[1]}); err != nil {
+		t.Fatalf("CreditCard() error = %v", err)
+	}
+	return srv, cl, cards
+}
+
+func TestCard_CSV(t *testing.T) {
+	_, cl, cards := fixture(t)
+
+	var buf bytes.Buffer
+	err := Card(context.Background(), cl, cards[0], &buf, Options{
+		Columns: []Column{ColumnDate, ColumnType, ColumnSignedAmount, ColumnBalanceAfter, ColumnNarrative},
+	})
+	if err != nil {
+		t.Fatalf("Card() error = %v", err)
+	}
+	want := "created_at,type,signed_amount,balance_after,narrative\n" +
+		"2023-06-01T12:00:00Z,debit,-12.50,37.50,mock debit\n" +
+		"2023-06-01T11:00:00Z,credit,50.00,50.00,\n"
+	if buf.String() != want {
+		t.Errorf("Card() wrote\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestIntegrator_JSONLines(t *testing.T) {
+	_, cl, cards := fixture(t)
+
+	var buf bytes.Buffer
+	err := Integrator(context.Background(), cl, &buf, Options{
+		Format:  JSONLines,
+		Columns: []Column{ColumnCard, ColumnAmount},
+		Amounts: WithCurrency,
+		From:    time.Date(2023, 6, 1, 11, 30, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Integrator() error = %v", err)
+	}
+	want := `{"card_id":"` + cards[0] + `","amount":"12.50 USD"}` + "\n" +
+		`{"card_id":"` + cards[1] + `","amount":"7.00 USD"}` + "\n"
+	if buf.String() != want {
+		t.Errorf("Integrator() wrote\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestCard_JSONLinesNumbers(t *testing.T) {
+	_, cl, cards := fixture(t)
+
+	tests := []struct {
+		amounts AmountFormat
+		want    string
+	}{
+		{Decimal, `{"type":"debit","signed_amount":-12.50,"balance_after":37.50}`},
+		{MinorUnits, `{"type":"debit","signed_amount":-1250,"balance_after":3750}`},
+		{WithCurrency, `{"type":"debit","signed_amount":"-12.50 USD","balance_after":"37.50 USD"}`},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		err := Card(context.Background(), cl, cards[0], &buf, Options{
+			Format:  JSONLines,
+			Columns: []Column{ColumnType, ColumnSignedAmount, ColumnBalanceAfter},
+			Amounts: tt.amounts,
+		})
+		if err != nil {
+			t.Fatalf("Card() error = %v", err)
+		}
+		if first := strings.SplitN(buf.String(), "\n", 2)[0]; first != tt.want {
+			t.Errorf("Card() with amounts %d wrote %s, want %s", tt.amounts, first, tt.want)
+		}
+	}
+}
+
+func TestCard_fromStopsPaging(t *testing.T) {
+	_, cl, cards := fixture(t)
+	for i := 0; i < 2; i++ {
+		if _, err := cl.MockTransaction(juice.MockTransactionData{Amount: juice.USDCents(100), Type: "debit"}, cards[0]); err != nil {
+			t.Fatalf("MockTransaction() error = %v", err)
+		}
+	}
+	// The card's history now runs 11:00, 12:00, 14:00 and 15:00.
+	pages := 0
+	cl.Use(func(next juice.HTTPClient) juice.HTTPClient {
+		return juice.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			if strings.HasSuffix(req.URL.Path, "/transactions") {
+				pages++
+			}
+			return next.Do(req)
+		})
+	})
+
+	var buf bytes.Buffer
+	err := Card(context.Background(), cl, cards[0], &buf, Options{
+		Columns:  []Column{ColumnDate},
+		From:     time.Date(2023, 6, 1, 13, 0, 0, 0, time.UTC),
+		PageSize: 1,
+	})
+	if err != nil {
+		t.Fatalf("Card() error = %v", err)
+	}
+	if want := "created_at\n2023-06-01T15:00:00Z\n2023-06-01T14:00:00Z\n"; buf.String() != want {
+		t.Errorf("Card() wrote\n%s\nwant\n%s", buf.String(), want)
+	}
+	if pages != 3 {
+		t.Errorf("Card() fetched %d pages, want 3: it should stop at the first transaction before From", pages)
+	}
+}
+
+func TestCSV_formulas(t *testing.T) {
+	var buf bytes.Buffer
+	enc := newCSVEncoder(&buf, Options{Columns: []Column{ColumnSignedAmount, ColumnNarrative}})
+	for _, memo := range []string{"=HYPERLINK(\"http://x\")", "+1", "-1", "@SUM(A1)", "coffee"} {
+		r := Record{Transaction: juice.Transaction{
+			Amount:            juice.USDCents(100),
+			CardBalanceBefore: juice.USDCents(100),
+			Narrative:         memo,
+		}}
+		if err := enc.write(r); err != nil {
+			t.Fatalf("write() error = %v", err)
+		}
+	}
+	if err := enc.close(); err != nil {
+		t.Fatalf("close() error = %v", err)
+	}
+	want := "signed_amount,narrative\n" +
+		"-1.00,\"'=HYPERLINK(\"\"http://x\"\")\"\n" +
+		"-1.00,'+1\n" +
+		"-1.00,'-1\n" +
+		"-1.00,'@SUM(A1)\n" +
+		"-1.00,coffee\n"
+	if buf.String() != want {
+		t.Errorf("CSV wrote\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestIntegrator_OFX(t *testing.T) {
+	_, cl, cards := fixture(t)
+
+	var buf bytes.Buffer
+	err := Integrator(context.Background(), cl, &buf, Options{
+		Format: OFX,
+		To:     time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("Integrator() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		`<?OFX OFXHEADER="200" VERSION="220"`,
+		"<ACCTID>" + cards[0] + "</ACCTID>",
+		"<ACCTID>" + cards[1] + "</ACCTID>",
+		"<DTSTART>20230601110000.000[0:GMT]</DTSTART><DTEND>20230701000000.000[0:GMT]</DTEND>",
+		"<TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20230601120000.000[0:GMT]</DTPOSTED><TRNAMT>-12.50</TRNAMT>",
+		"<MEMO>mock debit</MEMO>",
+		"<LEDGERBAL><BALAMT>37.50</BALAMT>",
+		"<LEDGERBAL><BALAMT>7.00</BALAMT>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("OFX output is missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "<CCSTMTRS>") != 2 || !strings.HasSuffix(out, "</CREDITCARDMSGSRSV1>\n</OFX>\n") {
+		t.Errorf("OFX output is not two complete statements:\n%s", out)
+	}
+}
+
+func TestUser_empty(t *testing.T) {
+	_, cl, _ := fixture(t)
+
+	var buf bytes.Buffer
+	if err := User(context.Background(), cl, "nobody", &buf, Options{Format: CSV}); err != nil {
+		t.Fatalf("User() error = %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "created_at,id,card_id") || strings.Count(buf.String(), "\n") != 1 {
+		t.Errorf("User() of a user without cards wrote %q, want only the header", buf.String())
+	}
+	if err := User(context.Background(), cl, "nobody", &buf, Options{Format: "xlsx"}); err == nil {
+		t.Errorf("User() with an unknown format succeeded")
+	}
+}
@@ -0,0 +1,45 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// jsonLinesEncoder writes one JSON object per record, with the columns as
+// fields in order. Number columns are written as JSON numbers unless amounts
+// carry their currency.
+type jsonLinesEncoder struct {
+	w       *bufio.Writer
+	columns []Column
+	amounts AmountFormat
+}
+
+func newJSONLinesEncoder(w io.Writer, opts Options) *jsonLinesEncoder {
+	return &jsonLinesEncoder{w: bufio.NewWriter(w), columns: opts.columns(), amounts: opts.Amounts}
+}
+
+func (e *jsonLinesEncoder) write(r Record) error {
+	e.w.WriteByte('{')
+	for i, c := range e.columns {
+		if i > 0 {
+			e.w.WriteByte(',')
+		}
+		name, _ := json.Marshal(c.Name)
+		value := c.Value(r, e.amounts)
+		e.w.Write(name)
+		e.w.WriteByte(':')
+		if c.Number && e.amounts != WithCurrency {
+			e.w.WriteString(value)
+			continue
+		}
+		quoted, _ := json.Marshal(value)
+		e.w.Write(quoted)
+	}
+	_, err := e.w.WriteString("}\n")
+	return err
+}
+
+func (e *jsonLinesEncoder) close() error {
+	return e.w.Flush()
+}
@@ -0,0 +1,117 @@
+package export
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ofxTime is the OFX date-time layout, always written in UTC.
+const ofxTime = "20060102150405.000[0:GMT]"
+
+// ofxEncoder writes an OFX 2.x document with one credit card statement per
+// card. A card's transactions are held until the next card starts, since the
+// statement header needs their date range and the closing balance.
+type ofxEncoder struct {
+	w       *bufio.Writer
+	opts    Options
+	now     func() time.Time
+	started bool
+	cardId  string
+	records []Record
+	trnuid  int
+}
+
+func newOFXEncoder(w io.Writer, opts Options) *ofxEncoder {
+	return &ofxEncoder{w: bufio.NewWriter(w), opts: opts, now: time.Now}
+}
+
+func (e *ofxEncoder) write(r Record) error {
+	e.begin()
+	if r.CardId != e.cardId && len(e.records) > 0 {
+		e.statement()
+	}
+	e.cardId = r.CardId
+	e.records = append(e.records, r)
+	return nil
+}
+
+func (e *ofxEncoder) close() error {
+	e.begin()
+	if len(e.records) > 0 {
+		e.statement()
+	}
+	e.w.WriteString("</CREDITCARDMSGSRSV1>\n</OFX>\n")
+	return e.w.Flush()
+}
+
+func (e *ofxEncoder) begin() {
+	if e.started {
+		return
+	}
+	e.started = true
+	e.w.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="no"?>` + "\n")
+	e.w.WriteString(`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n")
+	e.w.WriteString("<OFX>\n<SIGNONMSGSRSV1><SONRS>")
+	e.w.WriteString("<STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>")
+	fmt.Fprintf(e.w, "<DTSERVER>%s</DTSERVER><LANGUAGE>ENG</LANGUAGE>", e.now().UTC().Format(ofxTime))
+	e.w.WriteString("</SONRS></SIGNONMSGSRSV1>\n<CREDITCARDMSGSRSV1>\n")
+}
+
+// statement writes the buffered records of one card.
+func (e *ofxEncoder) statement() {
+	records := e.records
+	e.records = nil
+	e.trnuid++
+
+	start, end := e.opts.From, e.opts.To
+	latest := records[0]
+	for _, r := range records {
+		if e.opts.From.IsZero() && (start.IsZero() || r.CreatedAt.Before(start)) {
+			start = r.CreatedAt
+		}
+		if e.opts.To.IsZero() && r.CreatedAt.After(end) {
+			end = r.CreatedAt
+		}
+		if r.CreatedAt.After(latest.CreatedAt) {
+			latest = r
+		}
+	}
+
+	fmt.Fprintf(e.w, "<CCSTMTTRNRS><TRNUID>%d</TRNUID><STATUS><CODE>0</CODE><SEVERITY>INFO</SEVERITY></STATUS>\n", e.trnuid)
+	fmt.Fprintf(e.w, "<CCSTMTRS><CURDEF>%s</CURDEF><CCACCTFROM><ACCTID>%s</ACCTID></CCACCTFROM>\n", escape(string(latest.Amount.Currency)), escape(records[0].CardId))
+	fmt.Fprintf(e.w, "<BANKTRANLIST><DTSTART>%s</DTSTART><DTEND>%s</DTEND>\n", start.UTC().Format(ofxTime), end.UTC().Format(ofxTime))
+	for _, r := range records {
+		amount := r.Signed()
+		kind := "CREDIT"
+		if amount.IsNegative() {
+			kind = "DEBIT"
+		}
+		fmt.Fprintf(e.w, "<STMTTRN><TRNTYPE>%s</TRNTYPE><DTPOSTED>%s</DTPOSTED><TRNAMT>%s</TRNAMT><FITID>%s</FITID><NAME>%s</NAME>",
+			kind, r.CreatedAt.UTC().Format(ofxTime), amount.Decimal(), escape(r.Id), escape(truncate(string(r.Type), 32)))
+		if memo := narrative(r); memo != "" {
+			fmt.Fprintf(e.w, "<MEMO>%s</MEMO>", escape(truncate(memo, 255)))
+		}
+		e.w.WriteString("</STMTTRN>\n")
+	}
+	e.w.WriteString("</BANKTRANLIST>\n")
+	fmt.Fprintf(e.w, "<LEDGERBAL><BALAMT>%s</BALAMT><DTASOF>%s</DTASOF></LEDGERBAL>\n", latest.CardBalanceAfter.Decimal(), latest.CreatedAt.UTC().Format(ofxTime))
+	e.w.WriteString("</CCSTMTRS></CCSTMTTRNRS>\n")
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// truncate cuts s to the OFX field length limit n, in runes.
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
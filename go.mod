@@ -5,5 +5,4 @@ go 1.17
 require (
 	github.com/asaskevich/govalidator v0.0.0-20210307081110-f21760c49a8d
 	github.com/google/go-querystring v1.1.0
-	github.com/joho/godotenv v1.4.0
 )
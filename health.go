@@ -0,0 +1,93 @@
+package juice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// apiVersionHeader carries the API version in requests and responses.
+const apiVersionHeader = "X-Api-Version"
+
+// HealthStatus is the outcome of a health check.
+type HealthStatus struct {
+	// Reachable is true when the live endpoint answered with a 2xx status.
+	Reachable bool `json:"reachable"`
+	// Latency is the round trip time of the live endpoint request.
+	Latency time.Duration `json:"latency"`
+	// Authenticated is true when an authenticated call with the client's API
+	// key succeeded.
+	Authenticated bool `json:"authenticated"`
+	// APIVersion is the version the server reported, if any.
+	APIVersion string `json:"api_version,omitempty"`
+}
+
+// Ready reports whether the API is reachable and accepts the client's key.
+func (h HealthStatus) Ready() bool {
+	return h.Reachable && h.Authenticated
+}
+
+// Health checks that Spend-Juice is live and that the client's API key works,
+// by calling the live endpoint and then GetFloat. The status is filled in as
+// far as the check got; the error says why it stopped.
+func (cl *Client) Health(ctx context.Context) (HealthStatus, error) {
+	var status HealthStatus
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cl.baseURL+"/health/live", nil)
+	if err != nil {
+		return status, err
+	}
+	start := time.Now()
+	r, err := cl.transport().Do(req)
+	status.Latency = time.Since(start)
+	if err != nil {
+		return status, fmt.Errorf("juice: health check: %w", err)
+	}
+	io.Copy(ioutil.Discard, r.Body)
+	r.Body.Close()
+
+	status.APIVersion = cl.observeVersion(r)
+	if r.StatusCode < 200 || r.StatusCode >= 300 {
+		return status, fmt.Errorf("juice: health check: live endpoint returned %d %s", r.StatusCode, http.StatusText(r.StatusCode))
+	}
+	status.Reachable = true
+
+	if _, err := cl.GetFloatCtx(ctx); err != nil {
+		return status, fmt.Errorf("juice: health check: %w", err)
+	}
+	status.Authenticated = true
+	return status, nil
+}
+
+// HealthHandler serves Health as a readiness probe: 200 when the API is ready
+// and 503 otherwise, with the status as JSON. Each probe is bounded by timeout;
+// a timeout of zero or less adds no deadline beyond the probe request's own.
+func (cl *Client) HealthHandler(timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		status, err := cl.Health(ctx)
+		body := struct {
+			HealthStatus
+			Error string `json:"error,omitempty"`
+		}{HealthStatus: status}
+		code := http.StatusOK
+		if err != nil {
+			body.Error = err.Error()
+			code = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(body)
+	})
+}
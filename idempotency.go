@@ -0,0 +1,60 @@
+package juice
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+)
+
+type idempotencyKeyCtx struct{}
+
+// idempotent is implemented by request payloads that carry an idempotency key.
+type idempotent interface {
+	idempotencyKey() string
+}
+
+// NewIdempotencyKey returns a random key suitable for identifying one logical
+// money-moving operation. Persist it alongside your own record of the operation
+// and reuse it when repeating the call, so Spend-Juice applies it only once.
+func NewIdempotencyKey() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("juice: unable to generate idempotency key: " + err.Error())
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// WithIdempotencyKey returns a copy of ctx carrying key for TopUpFloatCtx,
+// whose payload has no IdempotencyKey field. Other calls ignore it, so one
+// request-scoped ctx never makes two different payments share a key.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtx{}, key)
+}
+
+// resolveIdempotencyKey returns the caller-supplied key, or a fresh one.
+func resolveIdempotencyKey(key string) string {
+	if key != "" {
+		return key
+	}
+	return NewIdempotencyKey()
+}
+
+// contextIdempotencyKey returns the key set on ctx, or a fresh one.
+func contextIdempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyCtx{}).(string)
+	return resolveIdempotencyKey(key)
+}
+
+func (d PaymentData) idempotencyKey() string {
+	return d.IdempotencyKey
+}
+
+func (d CreateCardData) idempotencyKey() string {
+	return d.IdempotencyKey
+}
+
+func (d TopUpFloatData) idempotencyKey() string {
+	return d.IdempotencyKey
+}
@@ -0,0 +1,156 @@
+package juice
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestClient_idempotencyKeys(t *testing.T) {
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	tests := []struct {
+		name    string
+		call    func(c *Client) error
+		wantKey string
+	}{
+		{
+			name: "CreditCard sends the caller's key",
+			call: func(c *Client) error {
+				_, err := c.CreditCard(PaymentData{Source: "integrator", Amount: USDCents(100), CardId: "card", IdempotencyKey: "credit-42"})
+				return err
+			},
+			wantKey: "credit-42",
+		},
+		{
+			name: "DebitCard generates a key when none is given",
+			call: func(c *Client) error {
+				_, err := c.DebitCard(PaymentData{Source: "integrator", Amount: USDCents(100), CardId: "card"})
+				return err
+			},
+		},
+		{
+			name: "CreateCard sends the caller's key",
+			call: func(c *Client) error {
+				_, err := c.CreateCard(CreateCardData{UserId: "user", Currency: USD, DesignType: DesignAurora, IdempotencyKey: "order-7"})
+				return err
+			},
+			wantKey: "order-7",
+		},
+		{
+			name: "TopUpFloatCtx reads the key from the context",
+			call: func(c *Client) error {
+				_, err := c.TopUpFloatCtx(WithIdempotencyKey(context.Background(), "float-1"), USDCents(500000))
+				return err
+			},
+			wantKey: "float-1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var keys []string
+			c := newTestClient()
+			c.SetRetryPolicy(RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond})
+			c.SetHTTPClient(&MockHttpClient{
+				DoFunc: func(r *http.Request) (*http.Response, error) {
+					keys = append(keys, r.Header.Get("Idempotency-Key"))
+					status := 200
+					if len(keys) == 1 {
+						status = 503
+					}
+					return &http.Response{
+						StatusCode: status,
+						Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{}`))),
+					}, nil
+				},
+			})
+
+			if err := tt.call(c); err != nil {
+				t.Fatalf("call error = %v", err)
+			}
+			if len(keys) != 2 {
+				t.Fatalf("expected the call to be retried once, got %d attempts", len(keys))
+			}
+			if keys[0] != keys[1] {
+				t.Errorf("retry sent key %q, first attempt sent %q", keys[1], keys[0])
+			}
+			if tt.wantKey != "" && keys[0] != tt.wantKey {
+				t.Errorf("Idempotency-Key = %q, want %q", keys[0], tt.wantKey)
+			}
+			if tt.wantKey == "" && !uuid.MatchString(keys[0]) {
+				t.Errorf("generated Idempotency-Key %q is not a v4 UUID", keys[0])
+			}
+		})
+	}
+}
+
+func TestClient_idempotencyKeyContextScope(t *testing.T) {
+	var keys []string
+	c := newTestClient()
+	c.SetHTTPClient(&MockHttpClient{
+		DoFunc: func(r *http.Request) (*http.Response, error) {
+			keys = append(keys, r.Header.Get("Idempotency-Key"))
+			return &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{}`))),
+			}, nil
+		},
+	})
+
+	ctx := WithIdempotencyKey(context.Background(), "request-1")
+	for _, card := range []string{"card-1", "card-2"} {
+		if _, err := c.CreditCardCtx(ctx, PaymentData{Source: "integrator", Amount: USDCents(100), CardId: card}); err != nil {
+			t.Fatalf("CreditCardCtx() error = %v", err)
+		}
+	}
+	if len(keys) != 2 {
+		t.Fatalf("got %d requests, want 2", len(keys))
+	}
+	if keys[0] == keys[1] {
+		t.Errorf("both credits sent Idempotency-Key %q", keys[0])
+	}
+	if keys[0] == "request-1" || keys[1] == "request-1" {
+		t.Errorf("CreditCardCtx used the context key: %v", keys)
+	}
+}
+
+func TestClient_idempotencyKeyReturned(t *testing.T) {
+	var sent string
+	c := newTestClient()
+	c.SetRetryPolicy(NoRetries)
+	c.SetHTTPClient(&MockHttpClient{
+		DoFunc: func(r *http.Request) (*http.Response, error) {
+			sent = r.Header.Get("Idempotency-Key")
+			return &http.Response{
+				StatusCode: 503,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"message":"Service unavailable"}`))),
+			}, nil
+		},
+	})
+
+	payment := PaymentData{Source: "integrator", Amount: USDCents(100), CardId: "card"}
+	credit, err := c.CreditCard(payment)
+	if err == nil {
+		t.Fatalf("CreditCard() expected the 503 error")
+	}
+	if credit.IdempotencyKey == "" || credit.IdempotencyKey != sent {
+		t.Errorf("CreditCard() returned key %q, sent %q", credit.IdempotencyKey, sent)
+	}
+	if payment.IdempotencyKey != "" {
+		t.Errorf("CreditCard() changed the caller's payment to key %q", payment.IdempotencyKey)
+	}
+
+	debit, _ := c.DebitCard(payment)
+	if debit.IdempotencyKey == "" || debit.IdempotencyKey != sent {
+		t.Errorf("DebitCard() returned key %q, sent %q", debit.IdempotencyKey, sent)
+	}
+
+	order, _ := c.CreateCard(CreateCardData{UserId: "user", Currency: USD, DesignType: DesignAurora})
+	if order.IdempotencyKey == "" || order.IdempotencyKey != sent {
+		t.Errorf("CreateCard() returned key %q, sent %q", order.IdempotencyKey, sent)
+	}
+}
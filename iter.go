@@ -0,0 +1,206 @@
+package juice
+
+import (
+	"context"
+)
+
+// defaultPageSize is used by the iterators when no page size is given.
+const defaultPageSize = 50
+
+// pager tracks which page an iterator fetches next. A zero next page means
+// the listing is exhausted.
+type pager struct {
+	next int
+	err  error
+}
+
+// UserIterator walks every card user attached to the account, fetching pages
+// as needed. Stop calling Next to end the walk early.
+//
+//	it := cl.Users(ctx, 100)
+//	for it.Next() {
+//		user := it.User()
+//	}
+//	if err := it.Err(); err != nil {
+//		return err
+//	}
+type UserIterator struct {
+	pager
+	fetch func(page int) ([]User, int, error)
+	items []User
+	cur   User
+}
+
+// Users returns an iterator over all card users, limit users per request.
+func (cl *Client) Users(ctx context.Context, limit int) *UserIterator {
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	return &UserIterator{
+		pager: pager{next: 1},
+		fetch: func(page int) ([]User, int, error) {
+			res, err := cl.ListUsersCtx(ctx, limit, page)
+			if err != nil {
+				return nil, 0, err
+			}
+			if len(res.Data) == 0 || res.Page >= res.TotalPages {
+				return res.Data, 0, nil
+			}
+			return res.Data, page + 1, nil
+		},
+	}
+}
+
+// Next advances to the next user, reporting false when there are no more
+// users or a request failed.
+func (it *UserIterator) Next() bool {
+	for len(it.items) == 0 {
+		if it.err != nil || it.next == 0 {
+			return false
+		}
+		it.items, it.next, it.err = it.fetch(it.next)
+	}
+	it.cur, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// User returns the user Next advanced to.
+func (it *UserIterator) User() User {
+	return it.cur
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *UserIterator) Err() error {
+	return it.err
+}
+
+// All drains the iterator into a slice.
+func (it *UserIterator) All() ([]User, error) {
+	var all []User
+	for it.Next() {
+		all = append(all, it.User())
+	}
+	return all, it.Err()
+}
+
+// CardIterator walks every card of a user, fetching pages as needed.
+type CardIterator struct {
+	pager
+	fetch func(page int) ([]CardResp, int, error)
+	items []CardResp
+	cur   CardResp
+}
+
+// Cards returns an iterator over all cards of userId, limit cards per request.
+// The cards listing reports no page count, so a short page ends the walk.
+func (cl *Client) Cards(ctx context.Context, userId string, limit int) *CardIterator {
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	return &CardIterator{
+		pager: pager{next: 1},
+		fetch: func(page int) ([]CardResp, int, error) {
+			res, err := cl.ListCardsCtx(ctx, limit, page, userId)
+			if err != nil {
+				return nil, 0, err
+			}
+			if len(res) < limit {
+				return res, 0, nil
+			}
+			return res, page + 1, nil
+		},
+	}
+}
+
+// Next advances to the next card, reporting false when there are no more
+// cards or a request failed.
+func (it *CardIterator) Next() bool {
+	for len(it.items) == 0 {
+		if it.err != nil || it.next == 0 {
+			return false
+		}
+		it.items, it.next, it.err = it.fetch(it.next)
+	}
+	it.cur, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// Card returns the card Next advanced to.
+func (it *CardIterator) Card() CardResp {
+	return it.cur
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *CardIterator) Err() error {
+	return it.err
+}
+
+// All drains the iterator into a slice.
+func (it *CardIterator) All() ([]CardResp, error) {
+	var all []CardResp
+	for it.Next() {
+		all = append(all, it.Card())
+	}
+	return all, it.Err()
+}
+
+// TransactionIterator walks every transaction of a card, following NextPage.
+type TransactionIterator struct {
+	pager
+	fetch func(page int) ([]Transaction, int, error)
+	items []Transaction
+	cur   Transaction
+}
+
+// Transactions returns an iterator over all transactions of cardId, limit
+// transactions per request.
+func (cl *Client) Transactions(ctx context.Context, cardId string, limit int) *TransactionIterator {
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	return &TransactionIterator{
+		pager: pager{next: 1},
+		fetch: func(page int) ([]Transaction, int, error) {
+			res, err := cl.ListTransactionsCtx(ctx, cardId, Param{Limit: limit, Page: page})
+			if err != nil {
+				return nil, 0, err
+			}
+			if res.NextPage == nil || *res.NextPage <= page {
+				return res.Data, 0, nil
+			}
+			return res.Data, *res.NextPage, nil
+		},
+	}
+}
+
+// Next advances to the next transaction, reporting false when there are no
+// more transactions or a request failed.
+func (it *TransactionIterator) Next() bool {
+	for len(it.items) == 0 {
+		if it.err != nil || it.next == 0 {
+			return false
+		}
+		it.items, it.next, it.err = it.fetch(it.next)
+	}
+	it.cur, it.items = it.items[0], it.items[1:]
+	return true
+}
+
+// Transaction returns the transaction Next advanced to.
+func (it *TransactionIterator) Transaction() Transaction {
+	return it.cur
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *TransactionIterator) Err() error {
+	return it.err
+}
+
+// All drains the iterator into a slice.
+func (it *TransactionIterator) All() ([]Transaction, error) {
+	var all []Transaction
+	for it.Next() {
+		all = append(all, it.Transaction())
+	}
+	return all, it.Err()
+}
@@ -0,0 +1,151 @@
+package juice
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func pagedClient(t *testing.T, pages map[int]string, fail map[int]bool) (*Client, *[]int) {
+	var requested []int
+	c := newTestClient()
+	c.SetRetryPolicy(NoRetries)
+	c.SetHTTPClient(&MockHttpClient{
+		DoFunc: func(r *http.Request) (*http.Response, error) {
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			if r.URL.Query().Get("limit") != "2" {
+				t.Errorf("Expected limit=2, got query %q", r.URL.RawQuery)
+			}
+			requested = append(requested, page)
+			status := 200
+			if fail[page] {
+				status = 404
+			}
+			return &http.Response{
+				StatusCode: status,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(pages[page]))),
+			}, nil
+		},
+	})
+	return c, &requested
+}
+
+func TestClient_Users(t *testing.T) {
+	pages := map[int]string{
+		1: `{"page":1,"total":3,"total_pages":2,"data":[{"id":"u1"},{"id":"u2"}]}`,
+		2: `{"page":2,"total":3,"total_pages":2,"data":[{"id":"u3"}]}`,
+	}
+	c, requested := pagedClient(t, pages, nil)
+
+	got, err := c.Users(context.Background(), 2).All()
+	if err != nil {
+		t.Fatalf("Users().All() error = %v", err)
+	}
+	if want := []User{{Id: "u1"}, {Id: "u2"}, {Id: "u3"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Users().All() = %v, want %v", got, want)
+	}
+	if want := []int{1, 2}; !reflect.DeepEqual(*requested, want) {
+		t.Errorf("Users() requested pages %v, want %v", *requested, want)
+	}
+}
+
+func TestClient_Cards(t *testing.T) {
+	pages := map[int]string{
+		1: `[{"id":"c1"},{"id":"c2"}]`,
+		2: `[{"id":"c3"},{"id":"c4"}]`,
+		3: `[]`,
+	}
+	c, requested := pagedClient(t, pages, nil)
+
+	it := c.Cards(context.Background(), "u1", 2)
+	var got []string
+	for it.Next() {
+		got = append(got, it.Card().Id)
+		if len(got) == 3 {
+			break
+		}
+	}
+	if it.Err() != nil {
+		t.Fatalf("Cards() error = %v", it.Err())
+	}
+	if want := []string{"c1", "c2", "c3"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Cards() = %v, want %v", got, want)
+	}
+	if want := []int{1, 2}; !reflect.DeepEqual(*requested, want) {
+		t.Errorf("Cards() stopped early but requested pages %v, want %v", *requested, want)
+	}
+}
+
+func TestClient_Transactions(t *testing.T) {
+	pages := map[int]string{
+		1: `{"data":[{"id":"t1"},{"id":"t2"}],"next_page":2}`,
+		2: `{"data":[{"id":"t3"},{"id":"t4"}],"next_page":3}`,
+		3: `{"errors":{"message":"Page not found"}}`,
+	}
+	c, _ := pagedClient(t, pages, map[int]bool{3: true})
+
+	got, err := c.Transactions(context.Background(), "c1", 2).All()
+	if err == nil {
+		t.Fatalf("Transactions().All() expected the page 3 error")
+	}
+	var ids []string
+	for _, trx := range got {
+		ids = append(ids, trx.Id)
+	}
+	if want := []string{"t1", "t2", "t3", "t4"}; fmt.Sprint(ids) != fmt.Sprint(want) {
+		t.Errorf("Transactions().All() = %v, want %v", ids, want)
+	}
+}
+
+func TestParam_query(t *testing.T) {
+	tests := []struct {
+		name string
+		call func(c *Client) error
+		want string
+	}{
+		{
+			name: "ListUsers",
+			call: func(c *Client) error { _, err := c.ListUsers(10, 3); return err },
+			want: "limit=10&page=3",
+		},
+		{
+			name: "ListCards",
+			call: func(c *Client) error { _, err := c.ListCards(10, 3, "u1"); return err },
+			want: "user_id=u1&limit=10&page=3",
+		},
+		{
+			name: "ListTransactions",
+			call: func(c *Client) error { _, err := c.ListTransactions("c1", Param{Limit: 10, Page: 3}); return err },
+			want: "limit=10&page=3",
+		},
+		{
+			name: "ListTransactions default page size",
+			call: func(c *Client) error { _, err := c.ListTransactions("c1", Param{}); return err },
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			c := newTestClient()
+			c.SetHTTPClient(&MockHttpClient{
+				DoFunc: func(r *http.Request) (*http.Response, error) {
+					got = r.URL.RawQuery
+					return &http.Response{
+						StatusCode: 200,
+						Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{}`))),
+					}, nil
+				},
+			})
+			tt.call(c)
+			if got != tt.want {
+				t.Errorf("%s sent query %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,22 +1,22 @@
 package juice
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"time"
 )
 
 type Account struct {
 	BusinessAddress    string      `json:"business_address"`
 	BusinessName       string      `json:"business_name"`
-	Chain              string      `json:"chain"`
+	Chain              Chain       `json:"chain"`
 	ContactNumber      string      `json:"contact_number"`
 	Country            string      `json:"country"`
 	Domain             string      `json:"domain"`
 	Email              string      `json:"email"`
 	FirstName          string      `json:"first_name"`
-	FloatCurrencies    []string    `json:"float_currencies"`
+	FloatCurrencies    []Currency  `json:"float_currencies"`
 	Id                 string      `json:"id"`
 	LastName           string      `json:"last_name"`
 	RegistrationNumber string      `json:"registration_number"`
@@ -24,9 +24,9 @@ type Account struct {
 }
 
 type UsdcAddress struct {
-	Address  string `json:"address"`
-	Chain    string `json:"chain"`
-	Currency string `json:"currency"`
+	Address  string   `json:"address"`
+	Chain    Chain    `json:"chain"`
+	Currency Currency `json:"currency"`
 }
 
 type User struct {
@@ -37,166 +37,355 @@ type User struct {
 	FirstName        string      `json:"first_name"`
 	Id               string      `json:"id"`
 	IdNumber         string      `json:"id_number"`
-	IdType           string      `json:"id_type"`
+	IdType           IdType      `json:"id_type"`
 	LastName         string      `json:"last_name"`
 	PhoneNumber      string      `json:"phone_number"`
 	Verified         bool        `json:"verified"`
 }
 
 type UserAddress struct {
-	City    string      `json:"city"`
-	Country string      `json:"country"`
-	Line1   string      `json:"line1"`
+	City    string      `json:"city" valid:"required"`
+	Country string      `json:"country" valid:"required,ISO3166Alpha2"`
+	Line1   string      `json:"line1" valid:"required"`
 	Line2   interface{} `json:"line2"`
 	State   interface{} `json:"state"`
 	ZipCode string      `json:"zip_code"`
 }
 
 type Card struct {
-	Balance    int       `json:"balance"`
-	BusinessId string    `json:"business_id"`
-	CardName   string    `json:"card_name"`
-	CardNumber string    `json:"card_number"`
-	CardType   string    `json:"card_type"`
-	Currency   string    `json:"currency"`
-	Cvv2       string    `json:"cvv2"`
-	DesignType string    `json:"design_type"`
-	Expiry     time.Time `json:"expiry"`
-	Id         string    `json:"id"`
-	Provider   string    `json:"provider"`
-	SingleUse  bool      `json:"single_use"`
-	Status     string    `json:"status"`
-	UserId     string    `json:"user_id"`
-	Valid      string    `json:"valid"`
+	Balance    Money      `json:"balance"`
+	BusinessId string     `json:"business_id"`
+	CardName   string     `json:"card_name"`
+	CardNumber string     `json:"card_number"`
+	CardType   CardType   `json:"card_type"`
+	Currency   Currency   `json:"currency"`
+	Cvv2       string     `json:"cvv2"`
+	DesignType DesignType `json:"design_type"`
+	Expiry     time.Time  `json:"expiry"`
+	Id         string     `json:"id"`
+	Provider   string     `json:"provider"`
+	SingleUse  bool       `json:"single_use"`
+	Status     CardStatus `json:"status"`
+	UserId     string     `json:"user_id"`
+	Valid      string     `json:"valid"`
 }
 
 type Transaction struct {
-	Amount            int         `json:"amount"`
-	CardBalanceAfter  int         `json:"card_balance_after"`
-	CardBalanceBefore int         `json:"card_balance_before"`
-	ConversionRate    int         `json:"conversion_rate"`
-	CreatedAt         time.Time   `json:"created_at"`
-	Currency          string      `json:"currency"`
-	Id                string      `json:"id"`
-	Narrative         interface{} `json:"narrative"`
-	Type              string      `json:"type"`
+	Amount            Money           `json:"amount"`
+	CardBalanceAfter  Money           `json:"card_balance_after"`
+	CardBalanceBefore Money           `json:"card_balance_before"`
+	ConversionRate    int             `json:"conversion_rate"`
+	CreatedAt         time.Time       `json:"created_at"`
+	CreditCurrency    interface{}     `json:"credit_currency"`
+	CreditId          interface{}     `json:"credit_id"`
+	Currency          Currency        `json:"currency"`
+	DebitCurrency     interface{}     `json:"debit_currency"`
+	DebitId           interface{}     `json:"debit_id"`
+	Id                string          `json:"id"`
+	Narrative         interface{}     `json:"narrative"`
+	Type              TransactionType `json:"type"`
+}
+
+// UnmarshalJSON decodes a card, tagging its balance with the card currency.
+func (c *Card) UnmarshalJSON(data []byte) error {
+	type card Card
+	if err := json.Unmarshal(data, (*card)(c)); err != nil {
+		return err
+	}
+	c.Balance = c.Balance.inCurrency(c.Currency)
+	return nil
+}
+
+// UnmarshalJSON decodes a transaction, tagging its amounts with the transaction currency.
+func (t *Transaction) UnmarshalJSON(data []byte) error {
+	type transaction Transaction
+	if err := json.Unmarshal(data, (*transaction)(t)); err != nil {
+		return err
+	}
+	t.Amount = t.Amount.inCurrency(t.Currency)
+	t.CardBalanceAfter = t.CardBalanceAfter.inCurrency(t.Currency)
+	t.CardBalanceBefore = t.CardBalanceBefore.inCurrency(t.Currency)
+	return nil
 }
 
 // RegisterAccount creates a card integrator account
 func (cl *Client) RegisterAccount(data RegisterAccountData) (AccountResp, error) {
+	return cl.RegisterAccountCtx(context.Background(), data)
+}
+
+// RegisterAccountCtx creates a card integrator account, aborting if ctx is done
+func (cl *Client) RegisterAccountCtx(ctx context.Context, data RegisterAccountData) (AccountResp, error) {
 	var res AccountResp
-	err := cl.post("/card-integrators/register-integrator", data, &res)
+	err := cl.post(ctx, "/card-integrators/register-integrator", data, &res)
 	return res, err
 }
 
 // UpdateAccount updates the card integrator account
 func (cl *Client) UpdateAccount(webhook, businessAddress, domain string) (AccountResp, error) {
+	return cl.UpdateAccountCtx(context.Background(), webhook, businessAddress, domain)
+}
+
+// UpdateAccountCtx updates the card integrator account, aborting if ctx is done
+func (cl *Client) UpdateAccountCtx(ctx context.Context, webhook, businessAddress, domain string) (AccountResp, error) {
 	var res AccountResp
-	err := cl.patch("/card-integrators/update", &UpdateAccountData{WebhookUrl: webhook, BusinessAddress: businessAddress, Domain: domain}, &res)
+	err := cl.patch(ctx, "/card-integrators/update", &UpdateAccountData{WebhookUrl: webhook, BusinessAddress: businessAddress, Domain: domain}, &res)
 	return res, err
 }
 
 // TopUpFloat allows an integrator to top up float balance.
-//This endpoint is only available in the sandbox environment.
-func (cl *Client) TopUpFloat(amount int) (Resp, error) {
+// This endpoint is only available in the sandbox environment; production
+// clients get ErrSandboxOnly.
+func (cl *Client) TopUpFloat(amount Money) (Resp, error) {
+	return cl.TopUpFloatCtx(context.Background(), amount)
+}
+
+// TopUpFloatCtx is TopUpFloat with a context for cancellation and deadlines.
+// Use WithIdempotencyKey on ctx to make a repeated top-up safe.
+func (cl *Client) TopUpFloatCtx(ctx context.Context, amount Money) (Resp, error) {
 	var res Resp
-	err := cl.patch("/card-integrators/top-up-float", &TopUpFloatData{amount}, &res)
+	if err := cl.require(EndpointTopUpFloat); err != nil {
+		return res, err
+	}
+	data := TopUpFloatData{Amount: amount, IdempotencyKey: contextIdempotencyKey(ctx)}
+	err := cl.patch(ctx, "/card-integrators/top-up-float", &data, &res)
 	return res, err
 }
 
 // GetFloat allows an integrator get their float balance
 func (cl *Client) GetFloat() (BalanceResp, error) {
+	return cl.GetFloatCtx(context.Background())
+}
+
+// GetFloatCtx gets the integrator float balance using the provided context
+func (cl *Client) GetFloatCtx(ctx context.Context) (BalanceResp, error) {
 	var res BalanceResp
-	err := cl.get("/card-integrators/float", nil, &res)
+	err := cl.get(ctx, "/card-integrators/float", nil, &res)
 	return res, err
 }
 
 // RegisterUser creates an account for user requesting a card
 func (cl *Client) RegisterUser(data RegisterUserData, accountId string) (UserResp, error) {
+	return cl.RegisterUserCtx(context.Background(), data, accountId)
+}
+
+// RegisterUserCtx creates a card user under accountId using the provided context
+func (cl *Client) RegisterUserCtx(ctx context.Context, data RegisterUserData, accountId string) (UserResp, error) {
 	var res UserResp
-	err := cl.post(fmt.Sprintf("/card-integrators/%s/register-user", accountId), data, &res)
+	err := cl.post(ctx, fmt.Sprintf("/card-integrators/%s/register-user", accountId), data, &res)
 	return res, err
 }
 
 // ListUsers gets list of card users attached to an account
 func (cl *Client) ListUsers(limit, page int) (UsersResp, error) {
+	return cl.ListUsersCtx(context.Background(), limit, page)
+}
+
+// ListUsersCtx gets a page of card users using the provided context
+func (cl *Client) ListUsersCtx(ctx context.Context, limit, page int) (UsersResp, error) {
 	var res UsersResp
-	err := cl.get("/card-integrators/card-users", Param{Limit: limit, Page: page}, &res)
+	err := cl.get(ctx, "/card-integrators/card-users", Param{Limit: limit, Page: page}, &res)
+	return res, err
+}
+
+// SearchUsers gets the card users registered with an email address
+func (cl *Client) SearchUsers(email string, limit, page int) (UsersResp, error) {
+	return cl.SearchUsersCtx(context.Background(), email, limit, page)
+}
+
+// SearchUsersCtx gets a page of the card users registered with an email address using the provided context
+func (cl *Client) SearchUsersCtx(ctx context.Context, email string, limit, page int) (UsersResp, error) {
+	var res UsersResp
+	err := cl.get(ctx, "/card-integrators/card-users", UserSearchParam{Email: email, Limit: limit, Page: page}, &res)
+	return res, err
+}
+
+// GetUser gets a particular card user, including whether they are verified
+func (cl *Client) GetUser(userId string) (UserResp, error) {
+	return cl.GetUserCtx(context.Background(), userId)
+}
+
+// GetUserCtx gets a particular card user using the provided context
+func (cl *Client) GetUserCtx(ctx context.Context, userId string) (UserResp, error) {
+	var res UserResp
+	err := cl.get(ctx, fmt.Sprintf("/card-integrators/card-users/%s", userId), nil, &res)
+	return res, err
+}
+
+// UpdateUser changes a card user's address or phone number
+func (cl *Client) UpdateUser(userId string, data UpdateUserData) (UserResp, error) {
+	return cl.UpdateUserCtx(context.Background(), userId, data)
+}
+
+// UpdateUserCtx changes a card user's address or phone number, aborting if ctx is done
+func (cl *Client) UpdateUserCtx(ctx context.Context, userId string, data UpdateUserData) (UserResp, error) {
+	var res UserResp
+	err := cl.patch(ctx, fmt.Sprintf("/card-integrators/card-users/%s", userId), data, &res)
+	return res, err
+}
+
+// ArchiveUser archives a card user
+func (cl *Client) ArchiveUser(userId string) (UserResp, error) {
+	return cl.ArchiveUserCtx(context.Background(), userId)
+}
+
+// ArchiveUserCtx archives a card user, aborting if ctx is done
+func (cl *Client) ArchiveUserCtx(ctx context.Context, userId string) (UserResp, error) {
+	var res UserResp
+	err := cl.patch(ctx, fmt.Sprintf("/card-integrators/card-users/%s/archive", userId), nil, &res)
+	return res, err
+}
+
+// UnarchiveUser restores an archived card user
+func (cl *Client) UnarchiveUser(userId string) (UserResp, error) {
+	return cl.UnarchiveUserCtx(context.Background(), userId)
+}
+
+// UnarchiveUserCtx restores an archived card user, aborting if ctx is done
+func (cl *Client) UnarchiveUserCtx(ctx context.Context, userId string) (UserResp, error) {
+	var res UserResp
+	err := cl.patch(ctx, fmt.Sprintf("/card-integrators/card-users/%s/unarchive", userId), nil, &res)
 	return res, err
 }
 
 // CreateCard creates a card for a user
 func (cl *Client) CreateCard(data CreateCardData) (CreateCardResp, error) {
+	return cl.CreateCardCtx(context.Background(), data)
+}
+
+// CreateCardCtx creates a card for a user, aborting if ctx is done
+func (cl *Client) CreateCardCtx(ctx context.Context, data CreateCardData) (CreateCardResp, error) {
+	data.IdempotencyKey = resolveIdempotencyKey(data.IdempotencyKey)
 	var res CreateCardResp
-	err := cl.post("/cards/create-virtual-card", data, &res)
+	err := cl.post(ctx, "/cards/create-virtual-card", data, &res)
+	res.IdempotencyKey = data.IdempotencyKey
 	return res, err
 }
 
 // ListCards gets a list of cards of a user
 func (cl *Client) ListCards(limit, page int, userId string) ([]CardResp, error) {
+	return cl.ListCardsCtx(context.Background(), limit, page, userId)
+}
+
+// ListCardsCtx gets a page of a user's cards using the provided context
+func (cl *Client) ListCardsCtx(ctx context.Context, limit, page int, userId string) ([]CardResp, error) {
 	var res []CardResp
-	err := cl.get(fmt.Sprintf("/cards?user_id=%s&limit=%d&page=%d", userId, limit, page), nil, &res)
+	err := cl.get(ctx, fmt.Sprintf("/cards?user_id=%s&limit=%d&page=%d", userId, limit, page), nil, &res)
 	return res, err
 }
 
 // GetCard gets a particular card
 func (cl *Client) GetCard(cardId string) (CardResp, error) {
+	return cl.GetCardCtx(context.Background(), cardId)
+}
+
+// GetCardCtx gets a particular card using the provided context
+func (cl *Client) GetCardCtx(ctx context.Context, cardId string) (CardResp, error) {
 	var res CardResp
-	err := cl.get(fmt.Sprintf("/cards/%s", cardId), nil, &res)
+	err := cl.get(ctx, fmt.Sprintf("/cards/%s", cardId), nil, &res)
 	return res, err
 }
 
 // CreditCard top-up a card for a user
 func (cl *Client) CreditCard(data PaymentData) (CardResp, error) {
+	return cl.CreditCardCtx(context.Background(), data)
+}
+
+// CreditCardCtx tops up a card for a user, aborting if ctx is done
+func (cl *Client) CreditCardCtx(ctx context.Context, data PaymentData) (CardResp, error) {
+	data.IdempotencyKey = resolveIdempotencyKey(data.IdempotencyKey)
 	var res CardResp
-	err := cl.patch("/cards/credit/balance", data, &res)
+	err := cl.patch(ctx, "/cards/credit/balance", data, &res)
+	res.IdempotencyKey = data.IdempotencyKey
 	return res, err
 }
 
 // DebitCard debits a card for a user
 func (cl *Client) DebitCard(data PaymentData) (CardResp, error) {
+	return cl.DebitCardCtx(context.Background(), data)
+}
+
+// DebitCardCtx debits a card for a user, aborting if ctx is done
+func (cl *Client) DebitCardCtx(ctx context.Context, data PaymentData) (CardResp, error) {
+	data.IdempotencyKey = resolveIdempotencyKey(data.IdempotencyKey)
 	var res CardResp
-	err := cl.patch("/cards/debit/balance", data, &res)
+	err := cl.patch(ctx, "/cards/debit/balance", data, &res)
+	res.IdempotencyKey = data.IdempotencyKey
 	return res, err
 }
 
 // FreezeCard freezes a card for a user
 func (cl *Client) FreezeCard(cardId string) (CardResp, error) {
+	return cl.FreezeCardCtx(context.Background(), cardId)
+}
+
+// FreezeCardCtx freezes a card for a user, aborting if ctx is done
+func (cl *Client) FreezeCardCtx(ctx context.Context, cardId string) (CardResp, error) {
 	var res CardResp
-	err := cl.patch(fmt.Sprintf("/cards/%s/freeze", cardId), nil, &res)
+	err := cl.patch(ctx, fmt.Sprintf("/cards/%s/freeze", cardId), nil, &res)
 	return res, err
 }
 
 // UnfreezeCard unfreezes a card for a user
 func (cl *Client) UnfreezeCard(cardId string) (CardResp, error) {
+	return cl.UnfreezeCardCtx(context.Background(), cardId)
+}
+
+// UnfreezeCardCtx unfreezes a card for a user, aborting if ctx is done
+func (cl *Client) UnfreezeCardCtx(ctx context.Context, cardId string) (CardResp, error) {
+	var res CardResp
+	err := cl.patch(ctx, fmt.Sprintf("/cards/%s/unfreeze", cardId), nil, &res)
+	return res, err
+}
+
+// TerminateCard permanently terminates a card. The card balance must be
+// zero; CloseCard sweeps it to the float first.
+func (cl *Client) TerminateCard(cardId string) (CardResp, error) {
+	return cl.TerminateCardCtx(context.Background(), cardId)
+}
+
+// TerminateCardCtx permanently terminates a card, aborting if ctx is done
+func (cl *Client) TerminateCardCtx(ctx context.Context, cardId string) (CardResp, error) {
 	var res CardResp
-	err := cl.patch(fmt.Sprintf("/cards/%s/unfreeze", cardId), nil, &res)
+	err := cl.patch(ctx, fmt.Sprintf("/cards/%s/terminate", cardId), nil, &res)
 	return res, err
 }
 
 // ListTransactions gets paginated transactions for the given card
 func (cl *Client) ListTransactions(cardId string, param Param) (TransactionsResp, error) {
+	return cl.ListTransactionsCtx(context.Background(), cardId, param)
+}
+
+// ListTransactionsCtx gets a page of transactions for the given card using the provided context
+func (cl *Client) ListTransactionsCtx(ctx context.Context, cardId string, param Param) (TransactionsResp, error) {
 	var res TransactionsResp
-	err := cl.get(fmt.Sprintf("/cards/%s/transactions", cardId), param, &res)
+	err := cl.get(ctx, fmt.Sprintf("/cards/%s/transactions", cardId), param, &res)
 	return res, err
 }
 
 // GetTransaction gets a particular transaction
 func (cl *Client) GetTransaction(trxId string) (TransactionResp, error) {
+	return cl.GetTransactionCtx(context.Background(), trxId)
+}
+
+// GetTransactionCtx gets a particular transaction using the provided context
+func (cl *Client) GetTransactionCtx(ctx context.Context, trxId string) (TransactionResp, error) {
 	var res TransactionResp
-	err := cl.get(fmt.Sprintf("/cards/transaction/%s", trxId), nil, &res)
+	err := cl.get(ctx, fmt.Sprintf("/cards/transaction/%s", trxId), nil, &res)
 	return res, err
 }
 
-//MockTransaction mocks card transaction. This endpoint is only available in the sandbox environment.
+// MockTransaction mocks card transaction. This endpoint is only available in the
+// sandbox environment; production clients get ErrSandboxOnly.
 func (cl *Client) MockTransaction(data MockTransactionData, cardId string) (Resp, error) {
-	var res Resp
-	err := cl.post(fmt.Sprintf("/cards/%s/mock-transaction", cardId), data, &res)
-	return res, err
+	return cl.MockTransactionCtx(context.Background(), data, cardId)
 }
 
-func (cl Client) Health() (string, error) {
-	resp, err := http.Get(fmt.Sprintf("%s/health/live", cl.baseURL))
-	body, _ := ioutil.ReadAll(resp.Body)
-	return string(body), err
+// MockTransactionCtx is MockTransaction with a context for cancellation and deadlines.
+func (cl *Client) MockTransactionCtx(ctx context.Context, data MockTransactionData, cardId string) (Resp, error) {
+	var res Resp
+	if err := cl.require(EndpointMockTransaction); err != nil {
+		return res, err
+	}
+	err := cl.post(ctx, fmt.Sprintf("/cards/%s/mock-transaction", cardId), data, &res)
+	return res, err
 }
@@ -2,10 +2,10 @@ package juice
 
 import (
 	"bytes"
-	"github.com/joho/godotenv"
+	"context"
+	"errors"
 	"io/ioutil"
 	"net/http"
-	"os"
 	"reflect"
 	"testing"
 	"time"
@@ -25,12 +25,17 @@ func (m *MockHttpClient) Do(req *http.Request) (*http.Response, error) {
 }
 
 func init() {
-	err := godotenv.Load("./.env")
-	if err != nil && os.Getenv("ENV") == "" {
+	cl = newTestClient()
+}
+
+// newTestClient returns a sandbox client. Tests replace its HTTP client with a
+// MockHttpClient, so the key is never sent to Spend-Juice.
+func newTestClient() *Client {
+	c, err := NewClient(WithAPIKey("sk_test_juice"))
+	if err != nil {
 		panic(err)
 	}
-	cl = NewClient()
-
+	return c
 }
 
 func TestClient_RegisterAccount(t *testing.T) {
@@ -96,7 +101,7 @@ func TestClient_RegisterAccount(t *testing.T) {
 					Domain:             "https://boro.com",
 					Email:              "boro@gmail.com",
 					FirstName:          "Olusola",
-					FloatCurrencies:    []string{"USD"},
+					FloatCurrencies:    []Currency{USD},
 					LastName:           "Alao",
 					Password:           "@Password",
 					RegistrationNumber: "RC-546787",
@@ -113,7 +118,7 @@ func TestClient_RegisterAccount(t *testing.T) {
 					Domain:             "https://boro.com",
 					Email:              "boro@gmail.com",
 					FirstName:          "Olusola",
-					FloatCurrencies:    []string{"USD"},
+					FloatCurrencies:    []Currency{USD},
 					Id:                 "27de9f46-726a-4499-aa62-27c3ed274026",
 					LastName:           "Alao",
 					RegistrationNumber: "RC-546787",
@@ -149,7 +154,7 @@ func TestClient_RegisterAccount(t *testing.T) {
 					Domain:             "https://olusolaa.tech",
 					Email:              "email@gmail.com",
 					FirstName:          "Olusola",
-					FloatCurrencies:    []string{"USD"},
+					FloatCurrencies:    []Currency{USD},
 					LastName:           "Alao",
 					Password:           "password",
 					RegistrationNumber: "12345",
@@ -236,7 +241,7 @@ func TestClient_UpdateAccount(t *testing.T) {
 					"https://olusolaa.tech",
 					"alaoolusolae@gmail.com",
 					"Olusola",
-					[]string{"USD"},
+					[]Currency{USD},
 					"8de0c7a2-0004-4420-899b-f8d89c81f82b",
 					"Alao",
 					"RC-546789",
@@ -323,7 +328,7 @@ func TestClient_GetFloat(t *testing.T) {
 				Timeout: 0,
 			},
 			want: BalanceResp{
-				Balance:  4445110,
+				Balance:  USDCents(4445110),
 				Currency: "USD",
 				Id:       "cdda21c2-7435-4d8c-a612-dcbad40f50d4",
 			},
@@ -347,7 +352,7 @@ func TestClient_GetFloat(t *testing.T) {
 
 func TestClient_topUpFloat(t *testing.T) {
 	type args struct {
-		amount int
+		amount Money
 	}
 	tests := []struct {
 		name           string
@@ -380,7 +385,7 @@ func TestClient_topUpFloat(t *testing.T) {
 				Timeout: 0,
 			},
 			args: args{
-				amount: 500000,
+				amount: USDCents(500000),
 			},
 			want: Resp{
 				Message: "Balance updated successfully",
@@ -407,7 +412,7 @@ func TestClient_topUpFloat(t *testing.T) {
 				Timeout: 0,
 			},
 			args: args{
-				amount: 5000000000,
+				amount: USDCents(5000000000),
 			},
 			want:    Resp{},
 			wantErr: true,
@@ -434,7 +439,7 @@ func TestClient_topUpFloat(t *testing.T) {
 				Timeout: 0,
 			},
 			args: args{
-				amount: 422,
+				amount: USDCents(422),
 			},
 			want:    Resp{},
 			wantErr: true,
@@ -770,12 +775,13 @@ func TestClient_CreateCard(t *testing.T) {
 				Source:           "integrator",
 				CardIntegratorId: "27de9f46-726a-4499-aa62-27c3ed274026",
 				Currency:         "USD",
-				JuiceUserId:      "be2c7d1c-c02a-4925-a7c4-4c5b4fc579f1",
+				UserId:           "be2c7d1c-c02a-4925-a7c4-4c5b4fc579f1",
 				Validity:         30,
+				IdempotencyKey:   "order-1",
 			}},
 			want: CreateCardResp{
 				Data: Card{
-					Balance:    0,
+					Balance:    USDCents(0),
 					BusinessId: "27de9f46-726a-4499-aa62-27c3ed274026",
 					CardName:   "Olusola Alao",
 					CardNumber: "5368988843030561",
@@ -791,6 +797,7 @@ func TestClient_CreateCard(t *testing.T) {
 					UserId:     "be2c7d1c-c02a-4925-a7c4-4c5b4fc579f1",
 					Valid:      "05/22",
 				},
+				IdempotencyKey: "order-1",
 			},
 			wantErr: false,
 		},
@@ -862,7 +869,7 @@ func TestClient_ListCards(t *testing.T) {
 			},
 			want: []CardResp{
 				{
-					Balance:    19900,
+					Balance:    USDCents(19900),
 					CardNumber: "5368988843030561",
 					CardType:   "virtual",
 					Cvv2:       "149",
@@ -936,7 +943,7 @@ func TestClient_GetCard(t *testing.T) {
 				id: "0c7ca765-764c-4f62-9c35-ac3e2abcee01",
 			},
 			want: CardResp{
-				Balance:    19900,
+				Balance:    USDCents(19900),
 				CardNumber: "5368988843030561",
 				CardType:   "virtual",
 				Cvv2:       "149",
@@ -1005,20 +1012,22 @@ func TestClient_CreditCard(t *testing.T) {
 				Timeout: 0,
 			},
 			args: args{PaymentData{
-				Source: "integrator",
-				Amount: 20000,
-				CardId: "0c7ca765-764c-4f62-9c35-ac3e2abcee01",
+				Source:         "integrator",
+				Amount:         USDCents(20000),
+				CardId:         "0c7ca765-764c-4f62-9c35-ac3e2abcee01",
+				IdempotencyKey: "credit-1",
 			}},
 			want: CardResp{
-				Balance:    20000,
-				CardNumber: "5368988843030561",
-				CardType:   "virtual",
-				Cvv2:       "149",
-				Expiry:     time.Date(2022, 05, 17, 00, 00, 00, +0000, time.UTC),
-				Id:         "0c7ca765-764c-4f62-9c35-ac3e2abcee01",
-				SingleUse:  false,
-				Status:     "active",
-				Valid:      "05/22",
+				Balance:        USDCents(20000),
+				CardNumber:     "5368988843030561",
+				CardType:       "virtual",
+				Cvv2:           "149",
+				Expiry:         time.Date(2022, 05, 17, 00, 00, 00, +0000, time.UTC),
+				Id:             "0c7ca765-764c-4f62-9c35-ac3e2abcee01",
+				SingleUse:      false,
+				Status:         "active",
+				Valid:          "05/22",
+				IdempotencyKey: "credit-1",
 			},
 			wantErr: false,
 		},
@@ -1079,20 +1088,22 @@ func TestClient_DebitCard(t *testing.T) {
 				Timeout: 0,
 			},
 			args: args{PaymentData{
-				Source: "integrator",
-				Amount: 100,
-				CardId: "0c7ca765-764c-4f62-9c35-ac3e2abcee01",
+				Source:         "integrator",
+				Amount:         USDCents(100),
+				CardId:         "0c7ca765-764c-4f62-9c35-ac3e2abcee01",
+				IdempotencyKey: "debit-1",
 			}},
 			want: CardResp{
-				Balance:    19900,
-				CardNumber: "5368988843030561",
-				CardType:   "virtual",
-				Cvv2:       "149",
-				Expiry:     time.Date(2022, 05, 17, 00, 00, 00, +0000, time.UTC),
-				Id:         "0c7ca765-764c-4f62-9c35-ac3e2abcee01",
-				SingleUse:  false,
-				Status:     "active",
-				Valid:      "05/22",
+				Balance:        USDCents(19900),
+				CardNumber:     "5368988843030561",
+				CardType:       "virtual",
+				Cvv2:           "149",
+				Expiry:         time.Date(2022, 05, 17, 00, 00, 00, +0000, time.UTC),
+				Id:             "0c7ca765-764c-4f62-9c35-ac3e2abcee01",
+				SingleUse:      false,
+				Status:         "active",
+				Valid:          "05/22",
+				IdempotencyKey: "debit-1",
 			},
 			wantErr: false,
 		},
@@ -1157,7 +1168,7 @@ func TestClient_FreezeCard(t *testing.T) {
 				id: "0c7ca765-764c-4f62-9c35-ac3e2abcee01",
 			},
 			want: CardResp{
-				Balance:    19900,
+				Balance:    USDCents(19900),
 				CardNumber: "5368988843030561",
 				CardType:   "virtual",
 				Cvv2:       "149",
@@ -1230,7 +1241,7 @@ func TestClient_UnfreezeCard(t *testing.T) {
 				id: "0c7ca765-764c-4f62-9c35-ac3e2abcee01",
 			},
 			want: CardResp{
-				Balance:    19900,
+				Balance:    USDCents(19900),
 				CardNumber: "5368988843030561",
 				CardType:   "virtual",
 				Cvv2:       "149",
@@ -1334,9 +1345,9 @@ func TestClient_ListTransactions(t *testing.T) {
 			want: TransactionsResp{
 				Data: []Transaction{
 					{
-						Amount:            100,
-						CardBalanceAfter:  19900,
-						CardBalanceBefore: 20000,
+						Amount:            USDCents(100),
+						CardBalanceAfter:  USDCents(19900),
+						CardBalanceBefore: USDCents(20000),
 						ConversionRate:    1,
 						CreatedAt:         time.Date(2022, 04, 17, 20, 55, 36, 798000000, time.UTC),
 						CreditCurrency:    nil,
@@ -1348,9 +1359,9 @@ func TestClient_ListTransactions(t *testing.T) {
 						Type:              "debit",
 					},
 					{
-						Amount:            20000,
-						CardBalanceAfter:  20000,
-						CardBalanceBefore: 0,
+						Amount:            USDCents(20000),
+						CardBalanceAfter:  USDCents(20000),
+						CardBalanceBefore: USDCents(0),
 						ConversionRate:    1,
 						CreatedAt:         time.Date(2022, 04, 17, 20, 41, 32, 483000000, time.UTC),
 						CreditCurrency:    "USD",
@@ -1435,9 +1446,9 @@ func TestClient_GetTransaction(t *testing.T) {
 			},
 			want: TransactionResp{
 				Data: Transaction{
-					Amount:            100,
-					CardBalanceAfter:  19900,
-					CardBalanceBefore: 20000,
+					Amount:            USDCents(100),
+					CardBalanceAfter:  USDCents(19900),
+					CardBalanceBefore: USDCents(20000),
 					ConversionRate:    1,
 					CreatedAt:         time.Date(2022, 04, 17, 20, 55, 36, 798000000, time.UTC),
 					CreditCurrency:    nil,
@@ -1504,7 +1515,7 @@ func TestClient_MockTransaction(t *testing.T) {
 			},
 			args: args{
 				MockTransactionData{
-					Amount: 20000,
+					Amount: USDCents(20000),
 					Type:   "deduct-reversal",
 				},
 				"fbea1aa0-d698-41c5-8372-5c08576d605e",
@@ -1531,26 +1542,89 @@ func TestClient_MockTransaction(t *testing.T) {
 	}
 }
 
+func TestClient_GetCardCtx(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cl.SetHTTPClient(&MockHttpClient{
+		DoFunc: func(r *http.Request) (*http.Response, error) {
+			if r.Context() != ctx {
+				t.Errorf("Expected request to carry the caller's context")
+			}
+			return nil, r.Context().Err()
+		},
+	})
+
+	_, err := cl.GetCardCtx(ctx, "0c7ca765-764c-4f62-9c35-ac3e2abcee01")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("GetCardCtx() error = %v, want %v", err, context.Canceled)
+	}
+}
+
 func TestClient_health(t *testing.T) {
+	respond := func(live, float int) MockHttpClient {
+		return MockHttpClient{
+			DoFunc: func(r *http.Request) (*http.Response, error) {
+				status, body := float, `{"balance":4445110,"currency":"USD"}`
+				if r.URL.Path == "/health/live" {
+					status, body = live, "OK"
+				}
+				if status == 0 {
+					return nil, errors.New("dial tcp: connection refused")
+				}
+				return &http.Response{
+					StatusCode: status,
+					Header:     http.Header{"X-Api-Version": {"2023-06-01"}},
+					Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+				}, nil
+			},
+		}
+	}
 	tests := []struct {
-		name    string
-		want    string
-		wantErr bool
+		name           string
+		mockHttpClient MockHttpClient
+		want           HealthStatus
+		wantErr        bool
 	}{
 		{
-			name: "Health check",
-			want: "OK",
+			name:           "Health check",
+			mockHttpClient: respond(200, 200),
+			want:           HealthStatus{Reachable: true, Authenticated: true, APIVersion: "2023-06-01"},
+		},
+		{
+			name:           "Health check (error; network down)",
+			mockHttpClient: respond(0, 0),
+			wantErr:        true,
+		},
+		{
+			name:           "Health check (error; not live)",
+			mockHttpClient: respond(503, 200),
+			want:           HealthStatus{APIVersion: "2023-06-01"},
+			wantErr:        true,
+		},
+		{
+			name:           "Health check (error; bad API key)",
+			mockHttpClient: respond(200, 401),
+			want:           HealthStatus{Reachable: true, APIVersion: "2023-06-01"},
+			wantErr:        true,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := cl.Health()
+			c := newTestClient()
+			c.SetRetryPolicy(NoRetries)
+			c.SetHTTPClient(&tt.mockHttpClient)
+			got, err := c.Health(context.Background())
 			if (err != nil) != tt.wantErr {
 				t.Errorf("health() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			got.Latency = 0
 			if got != tt.want {
-				t.Errorf("health() got = %v, want %v", got, tt.want)
+				t.Errorf("health() got = %+v, want %+v", got, tt.want)
+			}
+			if got.Ready() != (err == nil) {
+				t.Errorf("health() Ready() = %v with error %v", got.Ready(), err)
 			}
 		})
 	}
@@ -0,0 +1,266 @@
+package juicetest
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+
+	juice "github.com/bushaHQ/spend-juice-go"
+)
+
+func (s *Server) createCard(r *http.Request) (int, interface{}) {
+	var data juice.CreateCardData
+	if status, body, ok := decode(r, &data); !ok {
+		return status, body
+	}
+	u := s.findUser(data.UserId)
+	if u == nil {
+		return errorBody(http.StatusNotFound, "User not found", nil)
+	}
+	if u.Archived {
+		return errorBody(http.StatusBadRequest, "User is archived", nil)
+	}
+	if data.Validity <= 0 {
+		data.Validity = 30
+	}
+	currency := data.Currency
+	if currency == "" {
+		currency = s.currency
+	}
+
+	expiry := s.Now().UTC().AddDate(0, 0, data.Validity).Truncate(24 * time.Hour)
+	c := &juice.Card{
+		Balance:    juice.NewMoney(0, currency),
+		BusinessId: data.CardIntegratorId,
+		CardName:   u.FirstName + " " + u.LastName,
+		CardNumber: fmt.Sprintf("5368%012d", rand.Int63n(1e12)),
+		CardType:   "virtual",
+		Currency:   currency,
+		Cvv2:       fmt.Sprintf("%03d", rand.Intn(1000)),
+		DesignType: data.DesignType,
+		Expiry:     expiry,
+		Id:         newID(),
+		Provider:   "juicetest",
+		SingleUse:  data.SingleUse,
+		Status:     juice.CardActive,
+		UserId:     u.Id,
+		Valid:      expiry.Format("01/06"),
+	}
+	s.cards = append(s.cards, c)
+	return http.StatusCreated, juice.CreateCardResp{Data: *c}
+}
+
+func (s *Server) listCards(r *http.Request) (int, interface{}) {
+	limit, page := pagination(r)
+	userId := r.URL.Query().Get("user_id")
+
+	var owned []*juice.Card
+	for _, c := range s.cards {
+		if c.UserId == userId {
+			owned = append(owned, c)
+		}
+	}
+
+	from, to := window(len(owned), limit, page)
+	res := []juice.CardResp{}
+	for _, c := range owned[from:to] {
+		res = append(res, cardResp(c))
+	}
+	return http.StatusOK, res
+}
+
+func (s *Server) getCard(id string) (int, interface{}) {
+	c := s.findCard(id)
+	if c == nil {
+		return errorBody(http.StatusNotFound, "Card not found", nil)
+	}
+	return http.StatusOK, cardResp(c)
+}
+
+func (s *Server) setCardStatus(id string, status juice.CardStatus) (int, interface{}) {
+	c := s.findCard(id)
+	if c == nil {
+		return errorBody(http.StatusNotFound, "Card not found", nil)
+	}
+	if c.Status == juice.CardTerminated {
+		return errorBody(http.StatusBadRequest, "Card is terminated", nil)
+	}
+	c.Status = status
+	return http.StatusOK, cardResp(c)
+}
+
+// terminateCard terminates a card for good. Its balance must have been
+// debited back to the float first. Terminating a terminated card succeeds.
+func (s *Server) terminateCard(id string) (int, interface{}) {
+	c := s.findCard(id)
+	if c == nil {
+		return errorBody(http.StatusNotFound, "Card not found", nil)
+	}
+	if c.Balance.Amount != 0 {
+		return errorBody(http.StatusBadRequest, "Card balance must be zero", nil)
+	}
+	c.Status = juice.CardTerminated
+	return http.StatusOK, cardResp(c)
+}
+
+// creditCard moves money from the float onto an active card.
+func (s *Server) creditCard(r *http.Request) (int, interface{}) {
+	var data juice.PaymentData
+	if status, body, ok := decode(r, &data); !ok {
+		return status, body
+	}
+	c := s.findCard(data.CardId)
+	if c == nil {
+		return errorBody(http.StatusNotFound, "Card not found", nil)
+	}
+	if data.Amount.Amount <= 0 {
+		return invalid("amount", "This field must be greater than 0.")
+	}
+	if c.Status != juice.CardActive {
+		return errorBody(http.StatusBadRequest, "Card is not active", nil)
+	}
+	if s.float < data.Amount.Amount {
+		return errorBody(http.StatusPaymentRequired, "Insufficient float balance", nil)
+	}
+
+	s.float -= data.Amount.Amount
+	s.record(c, juice.TransactionCredit, data.Amount.Amount, nil)
+	return http.StatusOK, cardResp(c)
+}
+
+// debitCard moves money from a card back to the float. Frozen cards can be
+// debited so their balance can be swept; terminated cards can't.
+func (s *Server) debitCard(r *http.Request) (int, interface{}) {
+	var data juice.PaymentData
+	if status, body, ok := decode(r, &data); !ok {
+		return status, body
+	}
+	c := s.findCard(data.CardId)
+	if c == nil {
+		return errorBody(http.StatusNotFound, "Card not found", nil)
+	}
+	if data.Amount.Amount <= 0 {
+		return invalid("amount", "This field must be greater than 0.")
+	}
+	if c.Status == juice.CardTerminated {
+		return errorBody(http.StatusBadRequest, "Card is terminated", nil)
+	}
+	if c.Balance.Amount < data.Amount.Amount {
+		return errorBody(http.StatusPaymentRequired, "Insufficient card balance", nil)
+	}
+
+	s.float += data.Amount.Amount
+	s.record(c, juice.TransactionDebit, data.Amount.Amount, nil)
+	return http.StatusOK, cardResp(c)
+}
+
+// mockTransaction simulates merchant activity on a card. "debit" and "deduct"
+// spend from the card, "credit" and "deduct-reversal" refund it.
+func (s *Server) mockTransaction(r *http.Request, id string) (int, interface{}) {
+	var data juice.MockTransactionData
+	if status, body, ok := decode(r, &data); !ok {
+		return status, body
+	}
+	c := s.findCard(id)
+	if c == nil {
+		return errorBody(http.StatusNotFound, "Card not found", nil)
+	}
+	if data.Amount.Amount <= 0 {
+		return invalid("amount", "This field must be greater than 0.")
+	}
+	if c.Status != juice.CardActive {
+		return errorBody(http.StatusBadRequest, "Card is not active", nil)
+	}
+
+	narrative := "mock " + string(data.Type)
+	switch data.Type {
+	case juice.TransactionDebit, juice.TransactionDeduct:
+		if c.Balance.Amount < data.Amount.Amount {
+			return errorBody(http.StatusPaymentRequired, "Insufficient card balance", nil)
+		}
+		s.record(c, juice.TransactionDebit, data.Amount.Amount, narrative)
+	case juice.TransactionCredit, juice.TransactionDeductReversal:
+		s.record(c, juice.TransactionCredit, data.Amount.Amount, narrative)
+	default:
+		return invalid("type", "This field must be one of debit, deduct, credit, deduct-reversal.")
+	}
+	return http.StatusOK, juice.Resp{Message: "Ok"}
+}
+
+func (s *Server) listTransactions(r *http.Request, id string) (int, interface{}) {
+	if s.findCard(id) == nil {
+		return errorBody(http.StatusNotFound, "Card not found", nil)
+	}
+	limit, page := pagination(r)
+	history := s.transactions[id]
+
+	// Newest first, like the API.
+	from, to := window(len(history), limit, page)
+	res := juice.TransactionsResp{Data: []juice.Transaction{}, Message: "Ok"}
+	for i := from; i < to; i++ {
+		res.Data = append(res.Data, history[len(history)-1-i])
+	}
+	if to < len(history) {
+		next := page + 1
+		res.NextPage = &next
+	}
+	return http.StatusOK, res
+}
+
+func (s *Server) getTransaction(id string) (int, interface{}) {
+	for _, history := range s.transactions {
+		for _, trx := range history {
+			if trx.Id == id {
+				return http.StatusOK, juice.TransactionResp{Data: trx, Message: "Ok"}
+			}
+		}
+	}
+	return errorBody(http.StatusNotFound, "Transaction not found", nil)
+}
+
+// record applies a balance change to c and appends it to the card's history.
+func (s *Server) record(c *juice.Card, kind juice.TransactionType, amount int64, narrative interface{}) {
+	trx := juice.Transaction{
+		Amount:            juice.NewMoney(amount, c.Balance.Currency),
+		CardBalanceBefore: c.Balance,
+		ConversionRate:    1,
+		CreatedAt:         s.Now().UTC(),
+		Currency:          c.Currency,
+		Id:                newID(),
+		Narrative:         narrative,
+		Type:              kind,
+	}
+	if kind == juice.TransactionCredit {
+		c.Balance.Amount += amount
+		trx.CreditCurrency, trx.CreditId = c.Currency, c.Id
+	} else {
+		c.Balance.Amount -= amount
+		trx.DebitCurrency, trx.DebitId = c.Currency, c.Id
+	}
+	trx.CardBalanceAfter = c.Balance
+	s.transactions[c.Id] = append(s.transactions[c.Id], trx)
+}
+
+func (s *Server) findCard(id string) *juice.Card {
+	for _, c := range s.cards {
+		if c.Id == id {
+			return c
+		}
+	}
+	return nil
+}
+
+func cardResp(c *juice.Card) juice.CardResp {
+	return juice.CardResp{
+		Balance:    c.Balance,
+		CardNumber: c.CardNumber,
+		CardType:   c.CardType,
+		Cvv2:       c.Cvv2,
+		Expiry:     c.Expiry,
+		Id:         c.Id,
+		SingleUse:  c.SingleUse,
+		Status:     c.Status,
+		Valid:      c.Valid,
+	}
+}
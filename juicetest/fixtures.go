@@ -0,0 +1,23 @@
+package juicetest
+
+import juice "github.com/bushaHQ/spend-juice-go"
+
+// UserData returns a RegisterUserData that passes validation, for a user with
+// the given email address and phone number.
+func UserData(email, phone string) juice.RegisterUserData {
+	return juice.RegisterUserData{
+		Address:     juice.UserAddress{City: "Lagos", Country: "NG", Line1: "Lekki Phase 1", ZipCode: "101233"},
+		Email:       email,
+		FirstName:   "Ada",
+		IdNumber:    "00000000000",
+		IdType:      juice.IdBVN,
+		LastName:    "Obi",
+		PhoneNumber: phone,
+	}
+}
+
+// CardData returns a CreateCardData that passes validation, for a USD card
+// of the given user.
+func CardData(userId string) juice.CreateCardData {
+	return juice.CreateCardData{UserId: userId, Currency: juice.USD, DesignType: juice.DesignAurora}
+}
@@ -0,0 +1,454 @@
+// Package juicetest provides an in-memory Spend-Juice API for tests.
+//
+// The server keeps real state: crediting a card moves money out of the float,
+// frozen cards refuse payments and every balance change is recorded as a
+// transaction, so a sequence of client calls behaves like it would against the
+// sandbox.
+//
+//	srv := juicetest.NewServer()
+//	defer srv.Close()
+//	cl := srv.Client()
+package juicetest
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	juice "github.com/bushaHQ/spend-juice-go"
+)
+
+// APIKey is the key the server accepts unless Server.APIKey is changed.
+const APIKey = "juicetest_key"
+
+// Sandbox limits on a single float top-up, in minor units.
+const (
+	MinTopUp int64 = 500000
+	MaxTopUp int64 = 2000000
+)
+
+// Server is a fake Spend-Juice API backed by httptest.Server.
+type Server struct {
+	*httptest.Server
+
+	// APIKey is the bearer token requests must carry.
+	APIKey string
+	// Environment is the API the server imitates. It defaults to Sandbox;
+	// in Production, sandbox-only endpoints answer 404.
+	Environment juice.Environment
+	// Now is the clock used for timestamps. It defaults to time.Now.
+	Now func() time.Time
+
+	mu           sync.Mutex
+	account      *juice.Account
+	webhookUrl   string
+	float        int64
+	currency     juice.Currency
+	users        []*juice.User
+	cards        []*juice.Card
+	transactions map[string][]juice.Transaction
+	replays      map[string]replay
+}
+
+// replay is a stored response for an idempotency key.
+type replay struct {
+	status int
+	body   []byte
+}
+
+// NewServer starts a server with an empty USD float. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		APIKey:       APIKey,
+		Environment:  juice.Sandbox,
+		Now:          time.Now,
+		currency:     juice.USD,
+		transactions: map[string][]juice.Transaction{},
+		replays:      map[string]replay{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Client returns a client configured to talk to the server.
+func (s *Server) Client() *juice.Client {
+	cl, err := juice.NewClient(
+		juice.WithAPIKey(s.APIKey),
+		juice.WithEnvironment(s.Environment),
+		juice.WithBaseURL(s.URL),
+		juice.WithHTTPClient(s.Server.Client()),
+	)
+	if err != nil {
+		panic("juicetest: " + err.Error())
+	}
+	cl.SetLogLevel(juice.LevelInfo)
+	return cl
+}
+
+// Float returns the current float balance.
+func (s *Server) Float() juice.Money {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return juice.NewMoney(s.float, s.currency)
+}
+
+// SetFloat sets the float balance, bypassing the sandbox top-up limits.
+func (s *Server) SetFloat(balance juice.Money) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.float = balance.Amount
+	s.currency = balance.Currency
+}
+
+// Card returns a copy of the card with the given id.
+func (s *Server) Card(id string) (juice.Card, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c := s.findCard(id); c != nil {
+		return *c, true
+	}
+	return juice.Card{}, false
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Request-Id", newID())
+
+	if r.URL.Path == "/health/live" {
+		w.Write([]byte("OK"))
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+s.APIKey {
+		writeError(w, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := r.Header.Get("Idempotency-Key")
+	if key != "" {
+		key = r.Method + " " + r.URL.Path + " " + key
+		if rep, ok := s.replays[key]; ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(rep.status)
+			w.Write(rep.body)
+			return
+		}
+	}
+
+	status, body := s.route(r)
+
+	data, _ := json.Marshal(body)
+	if key != "" && status < 500 {
+		s.replays[key] = replay{status: status, body: data}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// route dispatches r and returns the status and JSON body to send.
+func (s *Server) route(r *http.Request) (int, interface{}) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case r.Method == http.MethodPost && path(parts, "card-integrators", "register-integrator"):
+		return s.registerIntegrator(r)
+	case r.Method == http.MethodPatch && path(parts, "card-integrators", "update"):
+		return s.updateIntegrator(r)
+	case r.Method == http.MethodPatch && path(parts, "card-integrators", "top-up-float") && s.Environment.Supports(juice.EndpointTopUpFloat):
+		return s.topUpFloat(r)
+	case r.Method == http.MethodGet && path(parts, "card-integrators", "float"):
+		return s.getFloat()
+	case r.Method == http.MethodPost && path(parts, "card-integrators", "*", "register-user"):
+		return s.registerUser(r, parts[1])
+	case r.Method == http.MethodGet && path(parts, "card-integrators", "card-users"):
+		return s.listUsers(r)
+	case r.Method == http.MethodGet && path(parts, "card-integrators", "card-users", "*"):
+		return s.getUser(parts[2])
+	case r.Method == http.MethodPatch && path(parts, "card-integrators", "card-users", "*"):
+		return s.updateUser(r, parts[2])
+	case r.Method == http.MethodPatch && path(parts, "card-integrators", "card-users", "*", "archive"):
+		return s.setUserArchived(parts[2], true)
+	case r.Method == http.MethodPatch && path(parts, "card-integrators", "card-users", "*", "unarchive"):
+		return s.setUserArchived(parts[2], false)
+
+	case r.Method == http.MethodPost && path(parts, "cards", "create-virtual-card"):
+		return s.createCard(r)
+	case r.Method == http.MethodGet && path(parts, "cards"):
+		return s.listCards(r)
+	case r.Method == http.MethodPatch && path(parts, "cards", "credit", "balance"):
+		return s.creditCard(r)
+	case r.Method == http.MethodPatch && path(parts, "cards", "debit", "balance"):
+		return s.debitCard(r)
+	case r.Method == http.MethodGet && path(parts, "cards", "transaction", "*"):
+		return s.getTransaction(parts[2])
+	case r.Method == http.MethodGet && path(parts, "cards", "*", "transactions"):
+		return s.listTransactions(r, parts[1])
+	case r.Method == http.MethodPatch && path(parts, "cards", "*", "freeze"):
+		return s.setCardStatus(parts[1], juice.CardFrozen)
+	case r.Method == http.MethodPatch && path(parts, "cards", "*", "unfreeze"):
+		return s.setCardStatus(parts[1], juice.CardActive)
+	case r.Method == http.MethodPatch && path(parts, "cards", "*", "terminate"):
+		return s.terminateCard(parts[1])
+	case r.Method == http.MethodPost && path(parts, "cards", "*", "mock-transaction") && s.Environment.Supports(juice.EndpointMockTransaction):
+		return s.mockTransaction(r, parts[1])
+	case r.Method == http.MethodGet && path(parts, "cards", "*"):
+		return s.getCard(parts[1])
+	}
+
+	return errorBody(http.StatusNotFound, "Not found", nil)
+}
+
+func (s *Server) registerIntegrator(r *http.Request) (int, interface{}) {
+	var data juice.RegisterAccountData
+	if status, body, ok := decode(r, &data); !ok {
+		return status, body
+	}
+	if s.account != nil && (s.account.Email == data.Email || s.account.ContactNumber == data.ContactNumber) {
+		return errorBody(http.StatusBadRequest, "", map[string]string{"message": "Email or phone number already exists"})
+	}
+
+	s.account = &juice.Account{
+		BusinessAddress:    data.BusinessAddress,
+		BusinessName:       data.BusinessName,
+		Chain:              data.Chain,
+		ContactNumber:      data.ContactNumber,
+		Country:            data.Country,
+		Domain:             data.Domain,
+		Email:              data.Email,
+		FirstName:          data.FirstName,
+		FloatCurrencies:    data.FloatCurrencies,
+		Id:                 newID(),
+		LastName:           data.LastName,
+		RegistrationNumber: data.RegistrationNumber,
+		UsdcAddress: juice.UsdcAddress{
+			Address:  "0x" + strings.Repeat("0", 40),
+			Chain:    data.Chain,
+			Currency: s.currency,
+		},
+	}
+	s.webhookUrl = data.WebhookUrl
+	return http.StatusCreated, juice.AccountResp{Data: *s.account}
+}
+
+func (s *Server) updateIntegrator(r *http.Request) (int, interface{}) {
+	var data juice.UpdateAccountData
+	if status, body, ok := decode(r, &data); !ok {
+		return status, body
+	}
+	if s.account == nil {
+		return errorBody(http.StatusNotFound, "Card integrator not found", nil)
+	}
+	if data.Domain != "" && !strings.HasPrefix(data.Domain, "http") {
+		return invalid("domain", "This field must be a valid URL.")
+	}
+
+	s.account.BusinessAddress = data.BusinessAddress
+	s.account.Domain = data.Domain
+	s.webhookUrl = data.WebhookUrl
+	return http.StatusOK, juice.AccountResp{Data: *s.account}
+}
+
+func (s *Server) topUpFloat(r *http.Request) (int, interface{}) {
+	var data juice.TopUpFloatData
+	if status, body, ok := decode(r, &data); !ok {
+		return status, body
+	}
+	if data.Amount.Amount < MinTopUp {
+		return invalid("amount", fmt.Sprintf("This field must be at least %d.", MinTopUp))
+	}
+	if data.Amount.Amount > MaxTopUp {
+		return invalid("amount", fmt.Sprintf("This field must be less than or equal %d.", MaxTopUp))
+	}
+
+	s.float += data.Amount.Amount
+	return http.StatusOK, juice.Resp{Message: "Ok"}
+}
+
+func (s *Server) getFloat() (int, interface{}) {
+	id := ""
+	if s.account != nil {
+		id = s.account.Id
+	}
+	return http.StatusOK, juice.BalanceResp{Balance: juice.NewMoney(s.float, s.currency), Currency: s.currency, Id: id}
+}
+
+func (s *Server) registerUser(r *http.Request, accountId string) (int, interface{}) {
+	var data juice.RegisterUserData
+	if status, body, ok := decode(r, &data); !ok {
+		return status, body
+	}
+	for _, u := range s.users {
+		if u.Email == data.Email || u.PhoneNumber == data.PhoneNumber {
+			return errorBody(http.StatusBadRequest, "", map[string]string{"message": "Email or phone number already exists"})
+		}
+	}
+
+	u := &juice.User{
+		Address:          data.Address,
+		CardIntegratorId: accountId,
+		Email:            data.Email,
+		FirstName:        data.FirstName,
+		Id:               newID(),
+		IdNumber:         data.IdNumber,
+		IdType:           data.IdType,
+		LastName:         data.LastName,
+		PhoneNumber:      data.PhoneNumber,
+		Verified:         true,
+	}
+	s.users = append(s.users, u)
+	return http.StatusCreated, juice.UserResp{Data: *u}
+}
+
+// listUsers lists users, only those with the given email when the email
+// query parameter is set.
+func (s *Server) listUsers(r *http.Request) (int, interface{}) {
+	users := s.users
+	if email := r.URL.Query().Get("email"); email != "" {
+		users = nil
+		for _, u := range s.users {
+			if strings.EqualFold(u.Email, email) {
+				users = append(users, u)
+			}
+		}
+	}
+
+	limit, page := pagination(r)
+	from, to := window(len(users), limit, page)
+	res := juice.UsersResp{
+		Page:       page,
+		Total:      len(users),
+		TotalPages: (len(users) + limit - 1) / limit,
+		Data:       []juice.User{},
+	}
+	for _, u := range users[from:to] {
+		res.Data = append(res.Data, *u)
+	}
+	return http.StatusOK, res
+}
+
+func (s *Server) getUser(id string) (int, interface{}) {
+	u := s.findUser(id)
+	if u == nil {
+		return errorBody(http.StatusNotFound, "User not found", nil)
+	}
+	return http.StatusOK, juice.UserResp{Data: *u}
+}
+
+func (s *Server) updateUser(r *http.Request, id string) (int, interface{}) {
+	var data juice.UpdateUserData
+	if status, body, ok := decode(r, &data); !ok {
+		return status, body
+	}
+	u := s.findUser(id)
+	if u == nil {
+		return errorBody(http.StatusNotFound, "User not found", nil)
+	}
+	if data.PhoneNumber != "" {
+		for _, other := range s.users {
+			if other != u && other.PhoneNumber == data.PhoneNumber {
+				return errorBody(http.StatusBadRequest, "", map[string]string{"message": "Email or phone number already exists"})
+			}
+		}
+		u.PhoneNumber = data.PhoneNumber
+	}
+	if data.Address != nil {
+		u.Address = *data.Address
+	}
+	return http.StatusOK, juice.UserResp{Data: *u}
+}
+
+func (s *Server) setUserArchived(id string, archived bool) (int, interface{}) {
+	u := s.findUser(id)
+	if u == nil {
+		return errorBody(http.StatusNotFound, "User not found", nil)
+	}
+	u.Archived = archived
+	return http.StatusOK, juice.UserResp{Data: *u}
+}
+
+func (s *Server) findUser(id string) *juice.User {
+	for _, u := range s.users {
+		if u.Id == id {
+			return u
+		}
+	}
+	return nil
+}
+
+// path reports whether parts matches want, where "*" matches any segment.
+func path(parts []string, want ...string) bool {
+	if len(parts) != len(want) {
+		return false
+	}
+	for i := range want {
+		if want[i] != "*" && want[i] != parts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func decode(r *http.Request, v interface{}) (int, interface{}, bool) {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		status, body := errorBody(http.StatusBadRequest, "Invalid JSON body", nil)
+		return status, body, false
+	}
+	return 0, nil, true
+}
+
+func pagination(r *http.Request) (limit, page int) {
+	limit, _ = strconv.Atoi(r.URL.Query().Get("limit"))
+	page, _ = strconv.Atoi(r.URL.Query().Get("page"))
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+	return limit, page
+}
+
+// window returns the slice bounds of page in a list of n items.
+func window(n, limit, page int) (from, to int) {
+	from = (page - 1) * limit
+	if from > n {
+		from = n
+	}
+	to = from + limit
+	if to > n {
+		to = n
+	}
+	return from, to
+}
+
+func errorBody(status int, message string, errors interface{}) (int, interface{}) {
+	return status, juice.Error{Message: message, Errors: errors}
+}
+
+func invalid(field, message string) (int, interface{}) {
+	return errorBody(http.StatusUnprocessableEntity, "Unprocessable entity", map[string][]string{field: {message}})
+}
+
+func writeError(w http.ResponseWriter, status int, message string, errors interface{}) {
+	_, body := errorBody(status, message, errors)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,324 @@
+package juicetest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	juice "github.com/bushaHQ/spend-juice-go"
+)
+
+func TestServer_cardLifecycle(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	cl := srv.Client()
+
+	account, err := cl.RegisterAccount(juice.RegisterAccountData{
+		BusinessName:    "Algo Math",
+		Email:           "boro@gmail.com",
+		ContactNumber:   "+2349099435568",
+		FloatCurrencies: []juice.Currency{juice.USD},
+		Chain:           juice.ChainETH,
+	})
+	if err != nil {
+		t.Fatalf("RegisterAccount() error = %v", err)
+	}
+	if _, err := cl.TopUpFloat(juice.USDCents(MaxTopUp)); err != nil {
+		t.Fatalf("TopUpFloat() error = %v", err)
+	}
+	user, err := cl.RegisterUser(UserData("user1@gmail.com", "+2348023547672"), account.Data.Id)
+	if err != nil {
+		t.Fatalf("RegisterUser() error = %v", err)
+	}
+	card, err := cl.CreateCard(juice.CreateCardData{UserId: user.Data.Id, CardIntegratorId: account.Data.Id, Currency: "USD", DesignType: juice.DesignAurora, Validity: 30})
+	if err != nil {
+		t.Fatalf("CreateCard() error = %v", err)
+	}
+	cardId := card.Data.Id
+
+	credit := juice.PaymentData{Source: "integrator", Amount: juice.USDCents(20000), CardId: cardId, IdempotencyKey: "credit-1"}
+	if _, err := cl.CreditCard(credit); err != nil {
+		t.Fatalf("CreditCard() error = %v", err)
+	}
+	if _, err := cl.CreditCard(credit); err != nil {
+		t.Fatalf("CreditCard() replay error = %v", err)
+	}
+	if _, err := cl.MockTransaction(juice.MockTransactionData{Amount: juice.USDCents(100), Type: "debit"}, cardId); err != nil {
+		t.Fatalf("MockTransaction() error = %v", err)
+	}
+
+	if _, err := cl.FreezeCard(cardId); err != nil {
+		t.Fatalf("FreezeCard() error = %v", err)
+	}
+	if _, err := cl.CreditCard(juice.PaymentData{Source: "integrator", Amount: juice.USDCents(100), CardId: cardId}); err == nil {
+		t.Errorf("CreditCard() on a frozen card succeeded")
+	}
+	got, err := cl.DebitCard(juice.PaymentData{Source: "integrator", Amount: juice.USDCents(4900), CardId: cardId})
+	if err != nil {
+		t.Fatalf("DebitCard() on a frozen card error = %v", err)
+	}
+
+	if got.Balance != juice.USDCents(15000) || got.Status != "frozen" {
+		t.Errorf("card after debit = %+v, want balance 15000 and status frozen", got)
+	}
+	if want := juice.USDCents(MaxTopUp - 20000 + 4900); srv.Float() != want {
+		t.Errorf("Float() = %v, want %v", srv.Float(), want)
+	}
+
+	history, err := cl.Transactions(context.Background(), cardId, 2).All()
+	if err != nil {
+		t.Fatalf("Transactions() error = %v", err)
+	}
+	var types []string
+	for _, trx := range history {
+		types = append(types, string(trx.Type))
+	}
+	if strings.Join(types, ",") != "debit,debit,credit" {
+		t.Errorf("transaction types newest first = %v", types)
+	}
+	if history[0].CardBalanceBefore != juice.USDCents(19900) || history[0].CardBalanceAfter != juice.USDCents(15000) {
+		t.Errorf("latest transaction = %+v", history[0])
+	}
+}
+
+func TestServer_errors(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	cl := srv.Client()
+
+	tests := []struct {
+		name   string
+		call   func() error
+		errMsg string
+		is     func(error) bool
+	}{
+		{
+			name:   "top up below the sandbox minimum",
+			call:   func() error { _, err := cl.TopUpFloat(juice.USDCents(MinTopUp - 1)); return err },
+			errMsg: "amount",
+		},
+		{
+			name:   "unknown card",
+			call:   func() error { _, err := cl.GetCard("missing"); return err },
+			errMsg: "card not found",
+			is:     juice.IsNotFound,
+		},
+		{
+			name: "wrong api key",
+			call: func() error {
+				other := srv.Client()
+				other.SetAuth("stolen")
+				_, err := other.GetFloat()
+				return err
+			},
+			errMsg: "unauthorized",
+			is:     juice.IsUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error = %v, want it to mention %q", err, tt.errMsg)
+			}
+			if tt.is != nil && !tt.is(err) {
+				t.Errorf("error = %#v, not matched by its Is helper", err)
+			}
+			var apiErr *juice.APIError
+			if errors.As(err, &apiErr) && apiErr.RequestId == "" {
+				t.Errorf("APIError.RequestId is empty")
+			}
+		})
+	}
+}
+
+func TestServer_production(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Environment = juice.Production
+
+	if _, err := srv.Client().TopUpFloat(juice.USDCents(MinTopUp)); !errors.Is(err, juice.ErrSandboxOnly) {
+		t.Errorf("TopUpFloat() error = %v, want ErrSandboxOnly", err)
+	}
+
+	sandbox, err := juice.NewClient(juice.WithAPIKey(srv.APIKey), juice.WithBaseURL(srv.URL), juice.WithHTTPClient(srv.Server.Client()))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if _, err := sandbox.TopUpFloat(juice.USDCents(MinTopUp)); !juice.IsNotFound(err) {
+		t.Errorf("TopUpFloat() against production error = %v, want a 404", err)
+	}
+}
+
+func TestServer_health(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	status, err := srv.Client().Health(context.Background())
+	if err != nil || !status.Ready() {
+		t.Fatalf("Health() = %+v, %v, want ready", status, err)
+	}
+
+	other := srv.Client()
+	other.SetAuth("stolen")
+	rec := httptest.NewRecorder()
+	other.HealthHandler(time.Second).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), `"authenticated":false`) {
+		t.Errorf("HealthHandler() with a bad key = %d %s, want 503", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	srv.Client().HealthHandler(0).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("HealthHandler(0) = %d %s, want 200 with no extra deadline", rec.Code, rec.Body.String())
+	}
+}
+
+func TestServer_users(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	cl := srv.Client()
+
+	var ids []string
+	for _, data := range []juice.RegisterUserData{
+		UserData("user1@gmail.com", "+2348023547672"),
+		UserData("user2@gmail.com", "+2348023547673"),
+	} {
+		user, err := cl.RegisterUser(data, "integrator")
+		if err != nil {
+			t.Fatalf("RegisterUser() error = %v", err)
+		}
+		ids = append(ids, user.Data.Id)
+	}
+
+	found, err := cl.SearchUsers("USER2@gmail.com", 10, 1)
+	if err != nil {
+		t.Fatalf("SearchUsers() error = %v", err)
+	}
+	if found.Total != 1 || len(found.Data) != 1 || found.Data[0].Id != ids[1] {
+		t.Errorf("SearchUsers() = %+v, want only user2", found)
+	}
+
+	address := juice.UserAddress{Line1: "12 Admiralty Way", City: "Lagos", Country: "NG", ZipCode: "101233"}
+	if _, err := cl.UpdateUser(ids[0], juice.UpdateUserData{PhoneNumber: "+2348023547673"}); err == nil {
+		t.Errorf("UpdateUser() to another user's phone number succeeded")
+	}
+	if _, err := cl.UpdateUser(ids[0], juice.UpdateUserData{Address: &address}); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	if _, err := cl.ArchiveUser(ids[0]); err != nil {
+		t.Fatalf("ArchiveUser() error = %v", err)
+	}
+	if _, err := cl.CreateCard(CardData(ids[0])); err == nil {
+		t.Errorf("CreateCard() for an archived user succeeded")
+	}
+
+	user, err := cl.GetUser(ids[0])
+	if err != nil {
+		t.Fatalf("GetUser() error = %v", err)
+	}
+	if !user.Data.Archived || !user.Data.Verified || user.Data.Address != address || user.Data.PhoneNumber != "+2348023547672" {
+		t.Errorf("GetUser() = %+v, want archived, verified, with the new address and old phone", user.Data)
+	}
+
+	if _, err := cl.UnarchiveUser(ids[0]); err != nil {
+		t.Fatalf("UnarchiveUser() error = %v", err)
+	}
+	if _, err := cl.CreateCard(CardData(ids[0])); err != nil {
+		t.Errorf("CreateCard() after unarchiving error = %v", err)
+	}
+	if _, err := cl.GetUser("missing"); !juice.IsNotFound(err) {
+		t.Errorf("GetUser() of a missing user error = %v, want not found", err)
+	}
+}
+
+func TestServer_closeCard(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.SetFloat(juice.USDCents(100000))
+	cl := srv.Client()
+
+	// The first terminate call is lost on the way.
+	failed := false
+	cl.Use(func(next juice.HTTPClient) juice.HTTPClient {
+		return juice.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			if strings.HasSuffix(req.URL.Path, "/terminate") && !failed {
+				failed = true
+				return nil, errors.New("connection reset by peer")
+			}
+			return next.Do(req)
+		})
+	})
+
+	user, err := cl.RegisterUser(UserData("user1@gmail.com", "+2348023547672"), "integrator")
+	if err != nil {
+		t.Fatalf("RegisterUser() error = %v", err)
+	}
+	card, err := cl.CreateCard(CardData(user.Data.Id))
+	if err != nil {
+		t.Fatalf("CreateCard() error = %v", err)
+	}
+	cardId := card.Data.Id
+	if _, err := cl.CreditCard(juice.PaymentData{Source: "integrator", Amount: juice.USDCents(5000), CardId: cardId}); err != nil {
+		t.Fatalf("CreditCard() error = %v", err)
+	}
+	if _, err := cl.TerminateCard(cardId); err == nil {
+		t.Fatalf("TerminateCard() with a balance succeeded")
+	}
+	failed = false
+
+	states := func(r juice.CloseReport) []juice.StepState {
+		var s []juice.StepState
+		for _, step := range r.Steps {
+			s = append(s, step.State)
+		}
+		return s
+	}
+
+	report, err := cl.CloseCard(context.Background(), cardId)
+	if err == nil || report.Done() {
+		t.Fatalf("CloseCard() = %+v, want the terminate step to fail", report)
+	}
+	if got, want := states(report), []juice.StepState{juice.StepDone, juice.StepDone, juice.StepFailed}; !reflect.DeepEqual(got, want) {
+		t.Errorf("first CloseCard() steps = %v, want %v", got, want)
+	}
+	if report.Swept != juice.USDCents(5000) || srv.Float() != juice.USDCents(100000) {
+		t.Errorf("swept %v, float %v; want 50.00 USD back on a 1000.00 USD float", report.Swept, srv.Float())
+	}
+
+	// Before resuming, the card is unfrozen and credited the same amount again.
+	if _, err := cl.UnfreezeCard(cardId); err != nil {
+		t.Fatalf("UnfreezeCard() error = %v", err)
+	}
+	if _, err := cl.CreditCard(juice.PaymentData{Source: "integrator", Amount: juice.USDCents(5000), CardId: cardId}); err != nil {
+		t.Fatalf("CreditCard() error = %v", err)
+	}
+
+	report, err = cl.CloseCard(context.Background(), cardId)
+	if err != nil || !report.Done() {
+		t.Fatalf("resumed CloseCard() = %+v, %v", report, err)
+	}
+	if got, want := states(report), []juice.StepState{juice.StepDone, juice.StepDone, juice.StepDone}; !reflect.DeepEqual(got, want) {
+		t.Errorf("resumed CloseCard() steps = %v, want %v", got, want)
+	}
+	if report.Swept != juice.USDCents(5000) || srv.Float() != juice.USDCents(100000) {
+		t.Errorf("swept %v, float %v; want the new 50.00 USD swept back", report.Swept, srv.Float())
+	}
+
+	if c, _ := srv.Card(cardId); c.Status != juice.CardTerminated {
+		t.Errorf("card status = %s, want terminated", c.Status)
+	}
+	if _, err := cl.UnfreezeCard(cardId); err == nil {
+		t.Errorf("UnfreezeCard() of a terminated card succeeded")
+	}
+
+	report, err = cl.CloseCard(context.Background(), cardId)
+	if got, want := states(report), []juice.StepState{juice.StepSkipped, juice.StepSkipped, juice.StepSkipped}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("CloseCard() of a closed card steps = %v, %v; want %v", got, err, want)
+	}
+}
@@ -0,0 +1,410 @@
+// Package ledger keeps a local double-entry book of the money an integrator
+// moves through Spend-Juice.
+//
+// Every movement is an Entry of postings that sum to zero per currency. Money
+// arrives in the Float account from Funding, moves between Float and the Card
+// accounts, and leaves a card into Spend when the cardholder pays a merchant.
+//
+// Entries are derived from client calls through Middleware and from webhook
+// deliveries through Register:
+//
+//	book := ledger.New()
+//	cl.Use(book.Middleware())
+//	book.Register(handler)
+//
+//	float := book.Balance(ledger.Float, juice.USD, ledger.Period{})
+package ledger
+
+import (
+	er "errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	juice "github.com/bushaHQ/spend-juice-go"
+)
+
+// Account names a ledger account.
+type Account string
+
+const (
+	// Funding is the outside world float top-ups come from. Its balance goes
+	// negative as the float is funded.
+	Funding Account = "funding"
+	// Float is the integrator float balance.
+	Float Account = "float"
+	// Spend is where card spend goes. Its balance grows with merchant payments
+	// and shrinks with refunds.
+	Spend Account = "spend"
+)
+
+const cardPrefix = "card:"
+
+// Card returns the account of a card.
+func Card(cardId string) Account {
+	return Account(cardPrefix + cardId)
+}
+
+// CardId returns the card an account belongs to, if it is a card account.
+func (a Account) CardId() (string, bool) {
+	if !strings.HasPrefix(string(a), cardPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(string(a), cardPrefix), true
+}
+
+// Posting moves Amount into Account; a negative Amount moves money out of it.
+type Posting struct {
+	Account Account
+	Amount  juice.Money
+}
+
+// Entry is one balanced movement of money.
+type Entry struct {
+	// Id identifies the movement, e.g. by idempotency key or transaction id,
+	// so recording it again has no effect.
+	Id          string
+	Time        time.Time
+	Description string
+	Postings    []Posting
+}
+
+var (
+	ErrNoPostings = er.New("ledger: entry has no postings")
+	ErrUnbalanced = er.New("ledger: postings do not sum to zero")
+)
+
+// Period limits a query to entries at or after From and before To. Zero values
+// leave the range open.
+type Period struct {
+	From, To time.Time
+}
+
+func (p Period) contains(t time.Time) bool {
+	return (p.From.IsZero() || !t.Before(p.From)) && (p.To.IsZero() || t.Before(p.To))
+}
+
+// Ledger is an in-memory book of entries. It is safe for concurrent use.
+type Ledger struct {
+	mu         sync.RWMutex
+	entries    []Entry
+	ids        map[string]bool
+	currencies map[Account]juice.Currency
+	pending    []pending
+	// calls and deliveries hold the ids of card transfers booked from one
+	// source that the other hasn't reported yet.
+	calls      map[transferKey][]string
+	deliveries map[transferKey][]string
+	now        func() time.Time
+}
+
+// New returns an empty ledger.
+func New() *Ledger {
+	return &Ledger{
+		ids:        map[string]bool{},
+		currencies: map[Account]juice.Currency{},
+		calls:      map[transferKey][]string{},
+		deliveries: map[transferKey][]string{},
+		now:        time.Now,
+	}
+}
+
+// SetCurrency sets the currency of account, Float or a Card. Middleware books
+// calls in the currency of the account they move money on, which the API
+// doesn't repeat in payment requests; it learns currencies from float and
+// card responses and from webhook deliveries, and SetCurrency fills in the
+// rest, e.g. for cards created before the ledger. Pending movements on
+// account are booked once its currency is set.
+func (l *Ledger) SetCurrency(account Account, currency juice.Currency) error {
+	if currency == "" {
+		return nil
+	}
+	l.mu.Lock()
+	l.currencies[account] = currency
+	var ready []pending
+	kept := l.pending[:0]
+	for _, p := range l.pending {
+		if p.account == account {
+			ready = append(ready, p)
+		} else {
+			kept = append(kept, p)
+		}
+	}
+	l.pending = kept
+	l.mu.Unlock()
+
+	for _, p := range ready {
+		if err := p.book(currency); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// currency returns the currency set or learned for account.
+func (l *Ledger) currency(account Account) (juice.Currency, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	c, ok := l.currencies[account]
+	return c, ok
+}
+
+// pending is a movement waiting for the currency of account.
+type pending struct {
+	account Account
+	entry   Entry
+	book    func(juice.Currency) error
+}
+
+// Pending returns the movements Middleware saw on accounts whose currency
+// isn't known yet, oldest first. Their amounts have no currency; SetCurrency
+// books them.
+func (l *Ledger) Pending() []Entry {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	entries := make([]Entry, len(l.pending))
+	for i, p := range l.pending {
+		entries[i] = p.entry
+	}
+	return entries
+}
+
+// hold keeps e until the currency of account is known, then books it with
+// book. It books right away when the currency is already known.
+func (l *Ledger) hold(account Account, e Entry, book func(juice.Currency) error) error {
+	l.mu.Lock()
+	currency, ok := l.currencies[account]
+	if !ok {
+		l.pending = append(l.pending, pending{account: account, entry: e, book: book})
+	}
+	l.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return book(currency)
+}
+
+// Record adds e to the ledger. An entry whose Id was already recorded is
+// ignored, so redelivered webhooks and retried calls are booked once.
+func (l *Ledger) Record(e Entry) error {
+	if err := check(e); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.add(e)
+	return nil
+}
+
+// add appends e unless its Id was seen. l.mu must be held.
+func (l *Ledger) add(e Entry) {
+	if e.Id != "" {
+		if l.ids[e.Id] {
+			return
+		}
+		l.ids[e.Id] = true
+	}
+	if e.Time.IsZero() {
+		e.Time = l.now()
+	}
+	e.Postings = append([]Posting(nil), e.Postings...)
+	l.entries = append(l.entries, e)
+}
+
+// transferKey identifies a card credit or debit that both a client call and a
+// webhook delivery may report, under different ids.
+type transferKey struct {
+	card     string
+	amount   juice.Money
+	incoming bool
+}
+
+// recordCall books e, a credit or debit made through the client. When a
+// delivery already booked the same transfer against Spend, that entry is
+// turned into e instead, so the transfer is booked once, against the float.
+func (l *Ledger) recordCall(e Entry, k transferKey) error {
+	if err := check(e); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ids[e.Id] {
+		return nil
+	}
+	if id, ok := take(l.deliveries, k); ok {
+		for i := range l.entries {
+			if l.entries[i].Id == id {
+				l.entries[i].Description = e.Description
+				l.entries[i].Postings = append([]Posting(nil), e.Postings...)
+			}
+		}
+		l.ids[e.Id] = true
+		return nil
+	}
+	l.add(e)
+	l.calls[k] = append(l.calls[k], e.Id)
+	return nil
+}
+
+// recordDelivery books e, a credit or debit transaction from a webhook,
+// unless a client call already booked the same transfer.
+func (l *Ledger) recordDelivery(e Entry, k transferKey) error {
+	if err := check(e); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.ids[e.Id] {
+		return nil
+	}
+	if _, ok := take(l.calls, k); ok {
+		l.ids[e.Id] = true
+		return nil
+	}
+	l.add(e)
+	l.deliveries[k] = append(l.deliveries[k], e.Id)
+	return nil
+}
+
+// take removes and returns the oldest id under k.
+func take(ids map[transferKey][]string, k transferKey) (string, bool) {
+	if len(ids[k]) == 0 {
+		return "", false
+	}
+	id := ids[k][0]
+	ids[k] = ids[k][1:]
+	return id, true
+}
+
+// check reports whether e has postings summing to zero per currency.
+func check(e Entry) error {
+	if len(e.Postings) == 0 {
+		return ErrNoPostings
+	}
+	sums := map[juice.Currency]int64{}
+	for _, p := range e.Postings {
+		sums[p.Amount.Currency] += p.Amount.Amount
+	}
+	for currency, sum := range sums {
+		if sum != 0 {
+			return fmt.Errorf("%w: %s off by %s", ErrUnbalanced, e.Id, juice.NewMoney(sum, currency))
+		}
+	}
+	return nil
+}
+
+// TopUp records amount funding the float.
+func (l *Ledger) TopUp(id string, amount juice.Money, at time.Time) error {
+	return l.Record(transfer(id, at, "float top-up", Funding, Float, amount))
+}
+
+// Credit records amount moving from the float to a card.
+func (l *Ledger) Credit(id, cardId string, amount juice.Money, at time.Time) error {
+	return l.Record(transfer(id, at, "card credit", Float, Card(cardId), amount))
+}
+
+// Debit records amount moving from a card back to the float.
+func (l *Ledger) Debit(id, cardId string, amount juice.Money, at time.Time) error {
+	return l.Record(transfer(id, at, "card debit", Card(cardId), Float, amount))
+}
+
+// Transaction records merchant activity on a card: spend moves money from the
+// card to Spend and refunds move it back. The direction is taken from the
+// card balance movement, or from the transaction type when the balance didn't
+// move.
+func (l *Ledger) Transaction(cardId string, t juice.Transaction) error {
+	return l.Record(merchant(cardId, t))
+}
+
+func merchant(cardId string, t juice.Transaction) Entry {
+	desc := "card " + string(t.Type)
+	if spends(t) {
+		return transfer(t.Id, t.CreatedAt, desc, Card(cardId), Spend, t.Amount)
+	}
+	return transfer(t.Id, t.CreatedAt, desc, Spend, Card(cardId), t.Amount)
+}
+
+func spends(t juice.Transaction) bool {
+	switch {
+	case t.CardBalanceAfter.Amount < t.CardBalanceBefore.Amount:
+		return true
+	case t.CardBalanceAfter.Amount > t.CardBalanceBefore.Amount:
+		return false
+	}
+	return t.Type.Outgoing()
+}
+
+func transfer(id string, at time.Time, desc string, from, to Account, amount juice.Money) Entry {
+	return Entry{
+		Id:          id,
+		Time:        at,
+		Description: desc,
+		Postings:    []Posting{{Account: from, Amount: amount.Neg()}, {Account: to, Amount: amount}},
+	}
+}
+
+// Balance returns the net amount posted to account in currency during p. With
+// an open start it is the account balance at the end of p.
+func (l *Ledger) Balance(account Account, currency juice.Currency, p Period) juice.Money {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	total := juice.NewMoney(0, currency)
+	for _, e := range l.entries {
+		if !p.contains(e.Time) {
+			continue
+		}
+		for _, posting := range e.Postings {
+			if posting.Account == account && posting.Amount.Currency == currency {
+				total.Amount += posting.Amount.Amount
+			}
+		}
+	}
+	return total
+}
+
+// Entries returns the entries of p touching account, oldest first. An empty
+// account matches every entry.
+func (l *Ledger) Entries(account Account, p Period) []Entry {
+	l.mu.RLock()
+	var entries []Entry
+	for _, e := range l.entries {
+		if p.contains(e.Time) && (account == "" || e.touches(account)) {
+			entries = append(entries, e)
+		}
+	}
+	l.mu.RUnlock()
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return entries
+}
+
+// Accounts lists every account with postings, sorted by name.
+func (l *Ledger) Accounts() []Account {
+	l.mu.RLock()
+	seen := map[Account]bool{}
+	var accounts []Account
+	for _, e := range l.entries {
+		for _, p := range e.Postings {
+			if !seen[p.Account] {
+				seen[p.Account] = true
+				accounts = append(accounts, p.Account)
+			}
+		}
+	}
+	l.mu.RUnlock()
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i] < accounts[j] })
+	return accounts
+}
+
+func (e Entry) touches(account Account) bool {
+	for _, p := range e.Postings {
+		if p.Account == account {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,238 @@
+package ledger
+
+import (
+	"context"
+	er "errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	juice "github.com/bushaHQ/spend-juice-go"
+	"github.com/bushaHQ/spend-juice-go/juicetest"
+	"github.com/bushaHQ/spend-juice-go/webhook"
+)
+
+var june = time.Date(2023, 6, 1, 9, 0, 0, 0, time.UTC)
+
+func TestRecord(t *testing.T) {
+	l := New()
+	tests := []struct {
+		name    string
+		entry   Entry
+		wantErr error
+	}{
+		{name: "balanced", entry: transfer("e1", june, "", Float, Card("c1"), juice.USDCents(500))},
+		{name: "repeated", entry: transfer("e1", june, "", Float, Card("c1"), juice.USDCents(500))},
+		{name: "no postings", entry: Entry{Id: "e2"}, wantErr: ErrNoPostings},
+		{name: "unbalanced", entry: Entry{Id: "e3", Postings: []Posting{{Float, juice.USDCents(-500)}, {Card("c1"), juice.USDCents(400)}}}, wantErr: ErrUnbalanced},
+		{name: "across currencies", entry: Entry{Id: "e4", Postings: []Posting{{Float, juice.USDCents(-500)}, {Card("c1"), juice.NewMoney(500, juice.EUR)}}}, wantErr: ErrUnbalanced},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := l.Record(tt.entry); !er.Is(err, tt.wantErr) {
+				t.Errorf("Record() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	if got := l.Balance(Card("c1"), juice.USD, Period{}); got != juice.USDCents(500) {
+		t.Errorf("Balance() = %v, want 5.00 USD booked once", got)
+	}
+}
+
+func TestBalance_period(t *testing.T) {
+	l := New()
+	l.TopUp("t1", juice.USDCents(10000), june)
+	l.Credit("c1", "card", juice.USDCents(3000), june.Add(time.Hour))
+	l.Transaction("card", juice.Transaction{Id: "tx1", Type: "debit", CreatedAt: june.Add(2 * time.Hour), Amount: juice.USDCents(1200)})
+	l.Debit("d1", "card", juice.USDCents(800), june.Add(24*time.Hour))
+
+	tests := []struct {
+		name    string
+		account Account
+		period  Period
+		want    int64
+	}{
+		{name: "float overall", account: Float, want: 7800},
+		{name: "funding overall", account: Funding, want: -10000},
+		{name: "card overall", account: Card("card"), want: 1000},
+		{name: "spend overall", account: Spend, want: 1200},
+		{name: "card on day one", account: Card("card"), period: Period{From: june, To: june.Add(24 * time.Hour)}, want: 1800},
+		{name: "float since day two", account: Float, period: Period{From: june.Add(24 * time.Hour)}, want: 800},
+		{name: "card before the credit", account: Card("card"), period: Period{To: june.Add(time.Hour)}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := l.Balance(tt.account, juice.USD, tt.period); got != juice.USDCents(tt.want) {
+				t.Errorf("Balance() = %v, want %v", got, juice.USDCents(tt.want))
+			}
+		})
+	}
+
+	want := []Account{Card("card"), Float, Funding, Spend}
+	if got := l.Accounts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Accounts() = %v, want %v", got, want)
+	}
+	if got := l.Entries(Card("card"), Period{}); len(got) != 3 || got[0].Id != "c1" || got[2].Id != "d1" {
+		t.Errorf("Entries() = %v, want c1, tx1, d1", got)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	srv := juicetest.NewServer()
+	defer srv.Close()
+	srv.SetFloat(juice.NewMoney(0, juice.EUR))
+	cl := srv.Client()
+	l := New()
+	cl.Use(l.Middleware())
+
+	// The float currency isn't known yet, so this top-up waits.
+	if _, err := cl.TopUpFloat(juice.NewMoney(500000, juice.EUR)); err != nil {
+		t.Fatalf("TopUpFloat() error = %v", err)
+	}
+	if got := l.Pending(); len(got) != 1 || got[0].Postings[1].Amount != juice.NewMoney(500000, "") {
+		t.Fatalf("Pending() = %v, want the top-up", got)
+	}
+	if _, err := cl.GetFloat(); err != nil {
+		t.Fatalf("GetFloat() error = %v", err)
+	}
+	if got := l.Pending(); len(got) != 0 {
+		t.Errorf("Pending() = %v after GetFloat, want none", got)
+	}
+	ctx := juice.WithIdempotencyKey(context.Background(), "top-up-1")
+	for i := 0; i < 2; i++ {
+		if _, err := cl.TopUpFloatCtx(ctx, juice.NewMoney(500000, juice.EUR)); err != nil {
+			t.Fatalf("TopUpFloat() error = %v", err)
+		}
+	}
+	user, err := cl.RegisterUser(juicetest.UserData("user1@gmail.com", "+2348023547672"), "integrator")
+	if err != nil {
+		t.Fatalf("RegisterUser() error = %v", err)
+	}
+	cardData := juicetest.CardData(user.Data.Id)
+	cardData.Currency = juice.EUR
+	card, err := cl.CreateCard(cardData)
+	if err != nil {
+		t.Fatalf("CreateCard() error = %v", err)
+	}
+	cardId := card.Data.Id
+	if _, err := cl.CreditCard(juice.PaymentData{Source: "integrator", Amount: juice.NewMoney(4000, juice.EUR), CardId: cardId}); err != nil {
+		t.Fatalf("CreditCard() error = %v", err)
+	}
+	if _, err := cl.DebitCard(juice.PaymentData{Source: "integrator", Amount: juice.NewMoney(1500, juice.EUR), CardId: cardId}); err != nil {
+		t.Fatalf("DebitCard() error = %v", err)
+	}
+	// Failed calls move no money.
+	if _, err := cl.DebitCard(juice.PaymentData{Source: "integrator", Amount: juice.NewMoney(999999, juice.EUR), CardId: cardId}); err == nil {
+		t.Fatalf("DebitCard() beyond the balance succeeded")
+	}
+
+	if got := l.Balance(Float, juice.EUR, Period{}); got != juice.NewMoney(997500, juice.EUR) {
+		t.Errorf("float balance = %v, want 9975.00 EUR", got)
+	}
+	if got := l.Balance(Card(cardId), juice.EUR, Period{}); got != juice.NewMoney(2500, juice.EUR) {
+		t.Errorf("card balance = %v, want 25.00 EUR", got)
+	}
+	if got := l.Balance(Float, juice.USD, Period{}); !got.IsZero() {
+		t.Errorf("float balance in USD = %v, want nothing booked in USD", got)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	l := New()
+	h := webhook.NewUnverifiedHandler()
+	l.Register(h)
+
+	deliveries := []string{
+		`{"id":"evt_1","event":"float.funded","created_at":"2023-06-01T09:00:00Z","data":{"amount":10000,"balance":10000,"currency":"USD"}}`,
+		`{"id":"evt_2","event":"transaction.authorized","created_at":"2023-06-01T10:00:00Z","data":{"id":"tx_1","type":"debit","amount":1200,"card_balance_before":3000,"card_balance_after":1800,"currency":"USD","card_id":"card"}}`,
+		`{"id":"evt_3","event":"transaction.authorized","created_at":"2023-06-01T11:00:00Z","data":{"id":"tx_2","type":"deduct-reversal","amount":200,"card_balance_before":1800,"card_balance_after":2000,"currency":"USD","card_id":"card"}}`,
+		`{"id":"evt_4","event":"transaction.declined","created_at":"2023-06-01T12:00:00Z","data":{"id":"tx_3","type":"debit","amount":9000,"currency":"USD","card_id":"card","reason":"insufficient funds"}}`,
+		// Redelivery of evt_2.
+		`{"id":"evt_2","event":"transaction.authorized","created_at":"2023-06-01T10:00:00Z","data":{"id":"tx_1","type":"debit","amount":1200,"card_balance_before":3000,"card_balance_after":1800,"currency":"USD","card_id":"card"}}`,
+	}
+	for _, d := range deliveries {
+		p, err := webhook.Parse([]byte(d))
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if err := h.Dispatch(context.Background(), p); err != nil {
+			t.Fatalf("Dispatch() error = %v", err)
+		}
+	}
+
+	for account, want := range map[Account]int64{Funding: -10000, Float: 10000, Card("card"): -1000, Spend: 1000} {
+		if got := l.Balance(account, juice.USD, Period{}); got != juice.USDCents(want) {
+			t.Errorf("Balance(%s) = %v, want %v", account, got, juice.USDCents(want))
+		}
+	}
+	if got, _ := l.currency(Card("card")); got != juice.USD {
+		t.Errorf("card currency = %q, want it learned from the deliveries", got)
+	}
+}
+
+func TestMiddlewareAndRegister(t *testing.T) {
+	srv := juicetest.NewServer()
+	defer srv.Close()
+	srv.SetFloat(juice.USDCents(100000))
+	cl := srv.Client()
+	l := New()
+	l.SetCurrency(Float, juice.USD)
+	cl.Use(l.Middleware())
+	h := webhook.NewUnverifiedHandler()
+	l.Register(h)
+
+	user, err := cl.RegisterUser(juicetest.UserData("user1@gmail.com", "+2348023547672"), "integrator")
+	if err != nil {
+		t.Fatalf("RegisterUser() error = %v", err)
+	}
+	card, err := cl.CreateCard(juicetest.CardData(user.Data.Id))
+	if err != nil {
+		t.Fatalf("CreateCard() error = %v", err)
+	}
+	cardId := card.Data.Id
+	if _, err := cl.CreditCard(juice.PaymentData{Source: "integrator", Amount: juice.USDCents(4000), CardId: cardId}); err != nil {
+		t.Fatalf("CreditCard() error = %v", err)
+	}
+	for _, mock := range []juice.MockTransactionData{{Amount: juice.USDCents(1200), Type: juice.TransactionDebit}, {Amount: juice.USDCents(200), Type: juice.TransactionCredit}} {
+		if _, err := cl.MockTransaction(mock, cardId); err != nil {
+			t.Fatalf("MockTransaction() error = %v", err)
+		}
+	}
+	if _, err := cl.DebitCard(juice.PaymentData{Source: "integrator", Amount: juice.USDCents(1500), CardId: cardId}); err != nil {
+		t.Fatalf("DebitCard() error = %v", err)
+	}
+
+	// Spend-Juice reports every transaction, some of them twice.
+	history, err := cl.ListTransactions(cardId, juice.Param{Limit: 10, Page: 1})
+	if err != nil {
+		t.Fatalf("ListTransactions() error = %v", err)
+	}
+	for _, tx := range append(history.Data, history.Data[0]) {
+		d := fmt.Sprintf(`{"id":"evt_%s","event":"transaction.authorized","created_at":"2023-06-01T10:00:00Z","data":{"id":%q,"type":%q,"amount":%d,"card_balance_before":%d,"card_balance_after":%d,"currency":"USD","card_id":%q}}`,
+			tx.Id, tx.Id, tx.Type, tx.Amount.Amount, tx.CardBalanceBefore.Amount, tx.CardBalanceAfter.Amount, cardId)
+		p, err := webhook.Parse([]byte(d))
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if err := h.Dispatch(context.Background(), p); err != nil {
+			t.Fatalf("Dispatch() error = %v", err)
+		}
+	}
+
+	for account, want := range map[Account]int64{Float: -2500, Card(cardId): 1500, Spend: 1000} {
+		if got := l.Balance(account, juice.USD, Period{}); got != juice.USDCents(want) {
+			t.Errorf("Balance(%s) = %v, want %v", account, got, juice.USDCents(want))
+		}
+	}
+
+	// A delivery arriving before the call is turned into the float transfer.
+	tx := juice.Transaction{Id: "tx_early", Type: juice.TransactionCredit, Amount: juice.USDCents(700), CreatedAt: june}
+	l.recordDelivery(merchant(cardId, tx), transferKey{cardId, tx.Amount, true})
+	l.recordCall(transfer("call_late", june, "card credit", Float, Card(cardId), tx.Amount), transferKey{cardId, tx.Amount, true})
+	for account, want := range map[Account]int64{Float: -3200, Card(cardId): 2200, Spend: 1000} {
+		if got := l.Balance(account, juice.USD, Period{}); got != juice.USDCents(want) {
+			t.Errorf("after an early delivery, Balance(%s) = %v, want %v", account, got, juice.USDCents(want))
+		}
+	}
+}
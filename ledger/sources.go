@@ -0,0 +1,146 @@
+package ledger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	juice "github.com/bushaHQ/spend-juice-go"
+	"github.com/bushaHQ/spend-juice-go/webhook"
+)
+
+// Middleware records successful float top-ups, card credits and card debits
+// made through the client it is added to. Entries are keyed by the request's
+// idempotency key, so retried requests are booked once.
+//
+// Payment requests carry no currency, so each call is booked in the currency
+// of the float or card it moves money on: Middleware learns it from GetFloat
+// and CreateCard responses, Register from webhook deliveries, and SetCurrency
+// from you. Until then the call waits in Pending.
+func (l *Ledger) Middleware() juice.Middleware {
+	return func(next juice.HTTPClient) juice.HTTPClient {
+		return juice.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			res, err := next.Do(req)
+			if err == nil && res.StatusCode >= 200 && res.StatusCode < 300 {
+				l.learn(req, res)
+				l.observe(req)
+			}
+			return res, err
+		})
+	}
+}
+
+// learn notes the float or card currency a response reports.
+func (l *Ledger) learn(req *http.Request, res *http.Response) {
+	path := req.URL.Path
+	float := req.Method == http.MethodGet && strings.HasSuffix(path, "/card-integrators/float")
+	card := req.Method == http.MethodPost && strings.HasSuffix(path, "/cards/create-virtual-card")
+	if (!float && !card) || res.Body == nil {
+		return
+	}
+	b, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	res.Body = io.NopCloser(bytes.NewReader(b))
+	if err != nil {
+		return
+	}
+	if float {
+		var balance juice.BalanceResp
+		if json.Unmarshal(b, &balance) == nil {
+			l.SetCurrency(Float, balance.Currency)
+		}
+		return
+	}
+	var created juice.CreateCardResp
+	if json.Unmarshal(b, &created) == nil && created.Data.Id != "" {
+		l.SetCurrency(Card(created.Data.Id), created.Data.Currency)
+	}
+}
+
+// observe books the money movement req made, if any.
+func (l *Ledger) observe(req *http.Request) {
+	if req.Method != http.MethodPatch || req.GetBody == nil {
+		return
+	}
+	path := req.URL.Path
+	topUp := strings.HasSuffix(path, "/card-integrators/top-up-float")
+	credit := strings.HasSuffix(path, "/cards/credit/balance")
+	if !topUp && !credit && !strings.HasSuffix(path, "/cards/debit/balance") {
+		return
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return
+	}
+	defer body.Close()
+	b, err := io.ReadAll(body)
+	if err != nil {
+		return
+	}
+	// TopUpFloatData only has the amount, which PaymentData shares.
+	var data juice.PaymentData
+	if json.Unmarshal(b, &data) != nil {
+		return
+	}
+	id, at, amount := req.Header.Get("Idempotency-Key"), l.now(), data.Amount.Amount
+
+	switch {
+	case topUp:
+		l.hold(Float, transfer(id, at, "float top-up", Funding, Float, juice.NewMoney(amount, "")), func(c juice.Currency) error {
+			return l.TopUp(id, juice.NewMoney(amount, c), at)
+		})
+	case credit:
+		l.hold(Card(data.CardId), transfer(id, at, "card credit", Float, Card(data.CardId), juice.NewMoney(amount, "")), func(c juice.Currency) error {
+			m := juice.NewMoney(amount, c)
+			return l.recordCall(transfer(id, at, "card credit", Float, Card(data.CardId), m), transferKey{data.CardId, m, true})
+		})
+	default:
+		l.hold(Card(data.CardId), transfer(id, at, "card debit", Card(data.CardId), Float, juice.NewMoney(amount, "")), func(c juice.Currency) error {
+			m := juice.NewMoney(amount, c)
+			return l.recordCall(transfer(id, at, "card debit", Card(data.CardId), Float, m), transferKey{data.CardId, m, false})
+		})
+	}
+}
+
+// Register books authorized card transactions and float fundings delivered to
+// h. Declined transactions move no money and are ignored. Card and float
+// currencies seen in deliveries are noted for Middleware.
+//
+// A credit or debit transaction may be a CreditCard or DebitCard call that
+// Middleware books between Float and the card, or merchant activity booked
+// against Spend. With both sources in use, a delivery and a call for the same
+// card, amount and direction are booked as one float transfer, whichever
+// arrives first. If your account also receives FloatFunded for top-ups made
+// with TopUpFloat, as sandbox accounts may, book the top-ups from one of the
+// two only.
+func (l *Ledger) Register(h *webhook.Handler) {
+	h.OnCardCreated(func(ctx context.Context, e webhook.CardEvent) error {
+		return l.SetCurrency(Card(e.Card.Id), e.Card.Currency)
+	})
+	h.OnTransactionAuthorized(func(ctx context.Context, e webhook.TransactionEvent) error {
+		t := e.Transaction
+		if t.Id == "" {
+			t.Id = e.Id
+		}
+		if t.CreatedAt.IsZero() {
+			t.CreatedAt = e.CreatedAt
+		}
+		if err := l.SetCurrency(Card(e.CardId), t.Currency); err != nil {
+			return err
+		}
+		if t.Type != juice.TransactionCredit && t.Type != juice.TransactionDebit {
+			return l.Transaction(e.CardId, t)
+		}
+		return l.recordDelivery(merchant(e.CardId, t), transferKey{e.CardId, t.Amount, !spends(t)})
+	})
+	h.OnFloatFunded(func(ctx context.Context, e webhook.FloatEvent) error {
+		if err := l.SetCurrency(Float, e.Amount.Currency); err != nil {
+			return err
+		}
+		return l.TopUp(e.Id, e.Amount, e.CreatedAt)
+	})
+}
@@ -0,0 +1,196 @@
+package juice
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// Level is the severity of a log message.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+	// LevelOff turns logging off when passed to SetLogLevel.
+	LevelOff
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return "OFF"
+}
+
+// Field is a key-value pair attached to a log message.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Logger receives the client's log messages. Field values reaching a Logger
+// have already been redacted.
+type Logger interface {
+	Log(level Level, msg string, fields ...Field)
+}
+
+// NewStdLogger returns a Logger writing "level msg key=value ..." lines to l,
+// or to the standard logger when l is nil.
+func NewStdLogger(l *log.Logger) Logger {
+	if l == nil {
+		l = log.Default()
+	}
+	return stdLogger{l}
+}
+
+type stdLogger struct {
+	l *log.Logger
+}
+
+func (s stdLogger) Log(level Level, msg string, fields ...Field) {
+	b := strings.Builder{}
+	b.WriteString("juice: " + level.String() + " " + msg)
+	for _, f := range fields {
+		b.WriteString(fmt.Sprintf(" %s=%v", f.Key, f.Value))
+	}
+	s.l.Print(b.String())
+}
+
+// SetLogger sets where the client logs to.
+func (cl *Client) SetLogger(logger Logger) {
+	cl.logger = logger
+}
+
+// SetLogLevel sets the lowest level the client logs at. At LevelDebug every
+// request and response is logged, with card numbers, CVVs, ID numbers, emails
+// and API keys redacted.
+func (cl *Client) SetLogLevel(level Level) {
+	cl.logLevel = level
+}
+
+// log sends msg to the client's logger if level is enabled, redacting every
+// field value.
+func (cl *Client) log(level Level, msg string, fields ...Field) {
+	if !cl.logs(level) {
+		return
+	}
+	for i, f := range fields {
+		switch v := f.Value.(type) {
+		case string:
+			fields[i].Value = Redact(v)
+		case fmt.Stringer, error:
+			fields[i].Value = Redact(fmt.Sprint(v))
+		}
+	}
+	cl.logger.Log(level, msg, fields...)
+}
+
+// logs reports whether messages at level are logged.
+func (cl *Client) logs(level Level) bool {
+	return cl.logger != nil && level >= cl.logLevel && cl.logLevel != LevelOff
+}
+
+// logParams logs the payload of a request at debug level.
+func (cl *Client) logParams(method, url string, params interface{}) {
+	if !cl.logs(LevelDebug) {
+		return
+	}
+	fields := []Field{{"method", method}, {"url", url}}
+	if params != nil {
+		data, _ := json.Marshal(params)
+		fields = append(fields, Field{"params", redactJSON(data)})
+	}
+	cl.log(LevelDebug, "request", fields...)
+}
+
+const redacted = "[REDACTED]"
+
+var (
+	panPattern    = regexp.MustCompile(`\b(?:\d[ -]?){9,15}(\d{4})\b`)
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+-]+(@|%40)([A-Za-z0-9.-]+\.[A-Za-z]{2,})`)
+	bearerPattern = regexp.MustCompile(`(?i)(bearer)\s+\S+`)
+)
+
+// sensitiveKeys maps JSON keys to how their values are redacted.
+var sensitiveKeys = map[string]func(string) string{
+	"card_number":   maskPAN,
+	"cvv":           func(string) string { return redacted },
+	"cvv2":          func(string) string { return redacted },
+	"id_number":     func(string) string { return redacted },
+	"password":      func(string) string { return redacted },
+	"authorization": func(string) string { return redacted },
+	"email":         maskEmail,
+}
+
+// Redact masks card numbers down to their last four digits, emails down to
+// their domain and bearer tokens entirely. Use it in your own logging of
+// Spend-Juice data.
+func Redact(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "$1 "+redacted)
+	s = panPattern.ReplaceAllString(s, "****$1")
+	return emailPattern.ReplaceAllString(s, "***$1$2")
+}
+
+func maskPAN(s string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+	if len(digits) < 4 {
+		return redacted
+	}
+	return "****" + digits[len(digits)-4:]
+}
+
+func maskEmail(s string) string {
+	if i := strings.LastIndexByte(s, '@'); i >= 0 {
+		return "***" + s[i:]
+	}
+	return redacted
+}
+
+// redactJSON returns a JSON document with sensitive values masked. Anything
+// that isn't JSON is redacted as plain text.
+func redactJSON(data []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return Redact(string(data))
+	}
+	out, _ := json.Marshal(redactValue("", v))
+	return string(out)
+}
+
+func redactValue(key string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, inner := range v {
+			v[k] = redactValue(k, inner)
+		}
+		return v
+	case []interface{}:
+		for i, inner := range v {
+			v[i] = redactValue(key, inner)
+		}
+		return v
+	case string:
+		if mask, ok := sensitiveKeys[strings.ToLower(key)]; ok {
+			return mask(v)
+		}
+		return Redact(v)
+	}
+	return v
+}
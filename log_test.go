@@ -0,0 +1,93 @@
+package juice
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Log(level Level, msg string, fields ...Field) {
+	line := level.String() + " " + msg
+	for _, f := range fields {
+		line += fmt.Sprintf(" %s=%v", f.Key, f.Value)
+	}
+	l.lines = append(l.lines, line)
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "card 4111111111111111 declined", want: "card ****1111 declined"},
+		{in: "card 4111 1111 1111 1111", want: "card ****1111"},
+		{in: "authorization: Bearer sk_live_abc123", want: "authorization: Bearer [REDACTED]"},
+		{in: "/users?email=joe.doe%40gmail.com", want: "/users?email=***%40gmail.com"},
+		{in: "contact joe@busha.co", want: "contact ***@busha.co"},
+		{in: "card 0c7ca765-764c-4f62-9c35-ac3e2abcee01", want: "card 0c7ca765-764c-4f62-9c35-ac3e2abcee01"},
+	}
+	for _, tt := range tests {
+		if got := Redact(tt.in); got != tt.want {
+			t.Errorf("Redact(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestClient_logRedactsCardData(t *testing.T) {
+	logger := &recordingLogger{}
+	c := newTestClient()
+	c.SetAuth("sk_test_secret")
+	c.SetLogger(logger)
+	c.SetLogLevel(LevelDebug)
+	c.SetHTTPClient(&MockHttpClient{
+		DoFunc: func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: 200,
+				Body: ioutil.NopCloser(bytes.NewReader([]byte(
+					`{"data":{"id":"card-1","card_number":"5399831234567890","cvv2":"123","balance":100}}`))),
+			}, nil
+		},
+	})
+
+	_, err := c.RegisterUser(RegisterUserData{
+		Email:       "user1@gmail.com",
+		FirstName:   "Joe",
+		LastName:    "Doe",
+		IdNumber:    "22233344455",
+		IdType:      "BVN",
+		PhoneNumber: "+2349034384664",
+		Address:     UserAddress{City: "Lagos", Country: "NG", Line1: "1 Str", State: "Lagos", ZipCode: "101233"},
+	}, "8de0c7a2-0004-4420-899b-f8d89c81f82b")
+	if err != nil {
+		t.Fatalf("RegisterUser() error = %v", err)
+	}
+	if _, err := c.GetCard("card-1"); err != nil {
+		t.Fatalf("GetCard() error = %v", err)
+	}
+
+	out := strings.Join(logger.lines, "\n")
+	for _, secret := range []string{"5399831234567890", `"123"`, "22233344455", "user1@", "sk_test_secret"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log leaks %q:\n%s", secret, out)
+		}
+	}
+	for _, kept := range []string{`"card_number":"****7890"`, `"email":"***@gmail.com"`, "card-1"} {
+		if !strings.Contains(out, kept) {
+			t.Errorf("log is missing %q:\n%s", kept, out)
+		}
+	}
+
+	logger.lines = nil
+	c.SetLogLevel(LevelInfo)
+	c.GetCard("card-1")
+	if len(logger.lines) != 0 {
+		t.Errorf("logged at LevelInfo: %q", logger.lines)
+	}
+}
@@ -0,0 +1,69 @@
+package juice
+
+import "net/http"
+
+// HTTPClientFunc adapts a function to the HTTPClient interface.
+type HTTPClientFunc func(req *http.Request) (*http.Response, error)
+
+// Do calls f(req).
+func (f HTTPClientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps the HTTPClient that sends API requests, like an
+// http.RoundTripper, to add headers, logging, metrics or tracing:
+//
+//	cl.Use(func(next juice.HTTPClient) juice.HTTPClient {
+//		return juice.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+//			req.Header.Set("X-Team", "payouts")
+//			return next.Do(req)
+//		})
+//	})
+//
+// Middleware runs once per attempt, inside retries and rate limiting.
+type Middleware func(next HTTPClient) HTTPClient
+
+// Use appends middleware to the client. The first middleware added is the
+// outermost one. The built-in JSONContentType, Authorization and APIVersion
+// middlewares always run first, so added middleware sees and may override
+// their headers.
+func (cl *Client) Use(mw ...Middleware) {
+	cl.middleware = append(cl.middleware, mw...)
+}
+
+// JSONContentType sets the Content-Type header to application/json.
+func JSONContentType(next HTTPClient) HTTPClient {
+	return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+		req.Header.Set("Content-Type", "application/json")
+		return next.Do(req)
+	})
+}
+
+// Authorization sets the authorization header to apiKey.
+func Authorization(apiKey string) Middleware {
+	return func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("authorization", apiKey)
+			return next.Do(req)
+		})
+	}
+}
+
+// chain wraps h in mw, the first middleware being the outermost.
+func chain(h HTTPClient, mw ...Middleware) HTTPClient {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// transport returns the client's HTTPClient wrapped in the built-in and
+// user middleware.
+func (cl *Client) transport() HTTPClient {
+	mw := []Middleware{JSONContentType, Authorization(cl.apiKey)}
+	if cl.apiVersion != "" {
+		mw = append(mw, APIVersion(cl.apiVersion))
+	}
+	mw = append(mw, cl.middleware...)
+	return chain(cl.httpClient, mw...)
+}
@@ -0,0 +1,84 @@
+package juice
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClient_Use(t *testing.T) {
+	var order []string
+	tag := func(name string) Middleware {
+		return func(next HTTPClient) HTTPClient {
+			return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name+" "+req.Header.Get("authorization")+" "+req.Header.Get("Content-Type"))
+				req.Header.Set("X-"+name, "1")
+				return next.Do(req)
+			})
+		}
+	}
+
+	var sent http.Header
+	attempts := 0
+	c := newTestClient()
+	c.SetAuth("sk_test")
+	c.SetRetryPolicy(RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond})
+	c.SetHTTPClient(&MockHttpClient{
+		DoFunc: func(r *http.Request) (*http.Response, error) {
+			attempts++
+			sent = r.Header.Clone()
+			status := 200
+			if attempts == 1 {
+				status = 503
+			}
+			return &http.Response{StatusCode: status, Body: ioutil.NopCloser(bytes.NewReader([]byte(`{}`)))}, nil
+		},
+	})
+	c.Use(tag("Outer"), tag("Inner"))
+
+	if _, err := c.GetFloat(); err != nil {
+		t.Fatalf("GetFloat() error = %v", err)
+	}
+
+	want := []string{
+		"Outer Bearer sk_test application/json",
+		"Inner Bearer sk_test application/json",
+		"Outer Bearer sk_test application/json",
+		"Inner Bearer sk_test application/json",
+	}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("middleware ran as %q, want %q", order, want)
+	}
+	if sent.Get("X-Outer") != "1" || sent.Get("X-Inner") != "1" {
+		t.Errorf("headers added by middleware were not sent: %v", sent)
+	}
+}
+
+func TestClient_UseShortCircuit(t *testing.T) {
+	c := newTestClient()
+	c.SetHTTPClient(&MockHttpClient{
+		DoFunc: func(r *http.Request) (*http.Response, error) {
+			t.Fatal("request reached the HTTP client")
+			return nil, nil
+		},
+	})
+	c.Use(func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"balance":100,"currency":"USD"}`))),
+			}, nil
+		})
+	})
+
+	got, err := c.GetFloat()
+	if err != nil {
+		t.Fatalf("GetFloat() error = %v", err)
+	}
+	if got.Balance != USDCents(100) {
+		t.Errorf("GetFloat() balance = %v, want 1.00 USD", got.Balance)
+	}
+}
@@ -0,0 +1,233 @@
+package juice
+
+import (
+	"bytes"
+	"encoding/json"
+	er "errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency code.
+type Currency string
+
+const (
+	USD Currency = "USD"
+	EUR Currency = "EUR"
+	GBP Currency = "GBP"
+	NGN Currency = "NGN"
+	GHS Currency = "GHS"
+	KES Currency = "KES"
+	ZAR Currency = "ZAR"
+	JPY Currency = "JPY"
+)
+
+// DefaultCurrency is assumed for amounts the API sends without a currency,
+// such as card balances in CardResp. Spend-Juice issues USD cards.
+const DefaultCurrency = USD
+
+// exponents maps currencies to their number of minor-unit digits. Currencies
+// missing from the map use two.
+var exponents = map[Currency]int{
+	JPY: 0,
+}
+
+var currencies = []string{string(USD), string(EUR), string(GBP), string(NGN), string(GHS), string(KES), string(ZAR), string(JPY)}
+
+// Known reports whether c is one of the Currency constants.
+func (c Currency) Known() bool { return known(string(c), currencies) }
+
+// MarshalJSON encodes c as a JSON string.
+func (c Currency) MarshalJSON() ([]byte, error) { return json.Marshal(string(c)) }
+
+// UnmarshalJSON decodes a currency code, upper-casing it. Codes this package
+// doesn't know are kept.
+func (c *Currency) UnmarshalJSON(data []byte) error {
+	s, err := decodeEnum(data, "currency", currencies)
+	*c = Currency(strings.ToUpper(s))
+	return err
+}
+
+// Exponent returns the number of digits after the decimal point, e.g. 2 for
+// USD where 1250 minor units are 12.50.
+func (c Currency) Exponent() int {
+	if e, ok := exponents[c]; ok {
+		return e
+	}
+	return 2
+}
+
+// ErrCurrencyMismatch is returned when combining amounts in different currencies.
+var ErrCurrencyMismatch = er.New("juice: currency mismatch")
+
+// Money is an amount in a currency's minor units, e.g. cents for USD.
+//
+// On the wire Spend-Juice sends amounts as bare integers. Money encodes to and
+// decodes from that integer; the currency comes from the enclosing object.
+// The currency of an amount in a request is therefore not sent: Spend-Juice
+// reads it in the currency of the card or float it applies to.
+type Money struct {
+	Amount   int64
+	Currency Currency
+}
+
+// NewMoney returns amount minor units of currency.
+func NewMoney(amount int64, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// USDCents returns cents as a USD amount.
+func USDCents(cents int64) Money {
+	return Money{Amount: cents, Currency: USD}
+}
+
+// ParseMoney parses a decimal amount followed by a currency code, such as
+// "12.50 USD" or "-3 NGN".
+func ParseMoney(s string) (Money, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return Money{}, fmt.Errorf("juice: invalid money %q: want \"<amount> <currency>\"", s)
+	}
+	currency := Currency(strings.ToUpper(fields[1]))
+	amount, err := parseMinor(fields[0], currency.Exponent())
+	if err != nil {
+		return Money{}, fmt.Errorf("juice: invalid money %q: %v", s, err)
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+func parseMinor(s string, exp int) (int64, error) {
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+	}
+	if len(frac) > exp {
+		return 0, fmt.Errorf("more than %d decimal places", exp)
+	}
+	if whole == "" || strings.Trim(whole+frac, "0123456789") != "" {
+		return 0, er.New("not a decimal number")
+	}
+
+	minor, err := strconv.ParseInt(whole+frac+strings.Repeat("0", exp-len(frac)), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if neg {
+		minor = -minor
+	}
+	return minor, nil
+}
+
+// Decimal formats the amount in major units without the currency, e.g. "12.50".
+func (m Money) Decimal() string {
+	exp := m.Currency.Exponent()
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+	digits := strconv.FormatInt(amount, 10)
+	if exp == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exp {
+		digits = strings.Repeat("0", exp-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exp] + "." + digits[len(digits)-exp:]
+}
+
+// String formats the amount with its currency, e.g. "12.50 USD".
+func (m Money) String() string {
+	return m.Decimal() + " " + string(m.Currency)
+}
+
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+// IsNegative reports whether the amount is below zero.
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Neg returns the amount with its sign flipped.
+func (m Money) Neg() Money {
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+// Add returns m+o, failing with ErrCurrencyMismatch across currencies.
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+// Sub returns m-o, failing with ErrCurrencyMismatch across currencies.
+func (m Money) Sub(o Money) (Money, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: m.Amount - o.Amount, Currency: m.Currency}, nil
+}
+
+// Cmp returns -1, 0 or 1 as m is less than, equal to or greater than o,
+// failing with ErrCurrencyMismatch across currencies.
+func (m Money) Cmp(o Money) (int, error) {
+	if err := m.sameCurrency(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func (m Money) sameCurrency(o Money) error {
+	if m.Currency != o.Currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+	}
+	return nil
+}
+
+// MarshalJSON encodes the amount as an integer number of minor units.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatInt(m.Amount, 10)), nil
+}
+
+// UnmarshalJSON decodes an integer number of minor units. The currency is
+// left as is, or set to DefaultCurrency when empty.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	var amount json.Number
+	if err := json.Unmarshal(data, &amount); err != nil {
+		return fmt.Errorf("juice: money amount must be a number: %w", err)
+	}
+	n, err := amount.Int64()
+	if err != nil {
+		return fmt.Errorf("juice: money amount must be whole minor units: %w", err)
+	}
+	m.Amount = n
+	if m.Currency == "" {
+		m.Currency = DefaultCurrency
+	}
+	return nil
+}
+
+// inCurrency returns m in currency, keeping m's currency when currency is empty.
+func (m Money) inCurrency(currency Currency) Money {
+	if currency != "" {
+		m.Currency = currency
+	}
+	return m
+}
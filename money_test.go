@@ -0,0 +1,95 @@
+package juice
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{in: "12.50 USD", want: USDCents(1250)},
+		{in: "12.5 usd", want: USDCents(1250)},
+		{in: "-3 NGN", want: NewMoney(-300, NGN)},
+		{in: "0.07 EUR", want: NewMoney(7, EUR)},
+		{in: "1500 JPY", want: NewMoney(1500, JPY)},
+		{in: "1.5 JPY", wantErr: true},
+		{in: "12.505 USD", wantErr: true},
+		{in: "12,50 USD", wantErr: true},
+		{in: ".50 USD", wantErr: true},
+		{in: "12.50", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := ParseMoney(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMoney() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("ParseMoney() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	tests := []struct {
+		in   Money
+		want string
+	}{
+		{in: USDCents(1250), want: "12.50 USD"},
+		{in: USDCents(5), want: "0.05 USD"},
+		{in: USDCents(-99), want: "-0.99 USD"},
+		{in: NewMoney(1500, JPY), want: "1500 JPY"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestMoney_arithmetic(t *testing.T) {
+	sum, err := USDCents(1250).Add(USDCents(50))
+	if err != nil || sum != USDCents(1300) {
+		t.Errorf("Add() = %v, %v, want 13.00 USD", sum, err)
+	}
+	diff, err := USDCents(1250).Sub(USDCents(1300))
+	if err != nil || diff != USDCents(-50) {
+		t.Errorf("Sub() = %v, %v, want -0.50 USD", diff, err)
+	}
+	if c, _ := USDCents(1).Cmp(USDCents(2)); c != -1 {
+		t.Errorf("Cmp() = %d, want -1", c)
+	}
+	if _, err := USDCents(1).Add(NewMoney(1, EUR)); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("Add() across currencies error = %v, want ErrCurrencyMismatch", err)
+	}
+}
+
+func TestMoney_JSON(t *testing.T) {
+	b, err := json.Marshal(PaymentData{Amount: USDCents(20000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"source":"","amount":20000,"card_id":""}`; string(b) != want {
+		t.Errorf("Marshal() = %s, want %s", b, want)
+	}
+
+	var resp BalanceResp
+	if err := json.Unmarshal([]byte(`{"balance":4445110,"currency":"EUR"}`), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if want := NewMoney(4445110, EUR); !reflect.DeepEqual(resp.Balance, want) {
+		t.Errorf("Unmarshal() balance = %#v, want %#v", resp.Balance, want)
+	}
+
+	var m Money
+	if err := json.Unmarshal([]byte(`12.5`), &m); err == nil {
+		t.Errorf("Unmarshal(12.5) succeeded, want an error")
+	}
+}
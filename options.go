@@ -0,0 +1,140 @@
+package juice
+
+import (
+	er "errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Environment selects the Spend-Juice API a client talks to.
+type Environment string
+
+const (
+	Sandbox    Environment = "sandbox"
+	Production Environment = "production"
+)
+
+const productionBaseURL = "https://api.spendjuice.com"
+
+// BaseURL returns the API root of the environment.
+func (e Environment) BaseURL() string {
+	if e == Production {
+		return productionBaseURL
+	}
+	return defaultBaseURL
+}
+
+// sandboxKeyPrefix starts every sandbox API key.
+const sandboxKeyPrefix = "sk_test_"
+
+var (
+	// ErrMissingAPIKey is returned by NewClient when no API key is given.
+	ErrMissingAPIKey = er.New("juice: no API key provided")
+	// ErrSandboxKey is returned by NewClient when a sandbox key is used
+	// with the Production environment.
+	ErrSandboxKey = er.New("juice: sandbox API key used in production")
+)
+
+// Option configures a Client created by NewClient.
+type Option func(*options)
+
+type options struct {
+	apiKey      string
+	environment Environment
+	baseURL     string
+	httpClient  HTTPClient
+	timeout     time.Duration
+	logger      Logger
+	apiVersion  string
+}
+
+// WithAPIKey sets the API key requests are authorized with.
+func WithAPIKey(apiKey string) Option {
+	return func(o *options) {
+		o.apiKey = apiKey
+	}
+}
+
+// WithEnvironment selects Sandbox or Production. The default is Sandbox.
+func WithEnvironment(env Environment) Option {
+	return func(o *options) {
+		o.environment = env
+	}
+}
+
+// WithBaseURL sends requests to baseURL instead of the environment's API,
+// e.g. to a juicetest server.
+func WithBaseURL(baseURL string) Option {
+	return func(o *options) {
+		o.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient sets the HTTP client that sends requests.
+func WithHTTPClient(httpClient HTTPClient) Option {
+	return func(o *options) {
+		o.httpClient = httpClient
+	}
+}
+
+// WithTimeout sets the timeout of the default HTTP client, or of the
+// *http.Client passed to WithHTTPClient.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithLogger sets where the client logs to.
+func WithLogger(logger Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithAPIVersion pins the API version sent with every request. See
+// Client.SetAPIVersion.
+func WithAPIVersion(version string) Option {
+	return func(o *options) {
+		o.apiVersion = version
+	}
+}
+
+// FromEnvironment reads the API key from JUICE_PRIVATE_KEY and selects
+// Production when ENV is "production". Options after it take precedence.
+func FromEnvironment() Option {
+	return func(o *options) {
+		if key := os.Getenv("JUICE_PRIVATE_KEY"); key != "" {
+			o.apiKey = key
+		}
+		if os.Getenv("ENV") == "production" {
+			o.environment = Production
+		}
+	}
+}
+
+// validate checks the options for mistakes that would only show up as failed
+// API calls.
+func (o *options) validate() error {
+	switch o.environment {
+	case Sandbox, Production:
+	default:
+		return fmt.Errorf("juice: unknown environment %q", o.environment)
+	}
+	key := strings.TrimPrefix(o.apiKey, "Bearer ")
+	if strings.TrimSpace(key) == "" {
+		return ErrMissingAPIKey
+	}
+	if o.environment == Production && strings.HasPrefix(key, sandboxKeyPrefix) {
+		return ErrSandboxKey
+	}
+	if o.timeout != 0 && o.httpClient != nil {
+		if _, ok := o.httpClient.(*http.Client); !ok {
+			return er.New("juice: WithTimeout needs WithHTTPClient to be given an *http.Client")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package juice
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestNewClient(t *testing.T) {
+	mock := &MockHttpClient{}
+	tests := []struct {
+		name      string
+		opts      []Option
+		wantErr   error
+		wantURL   string
+		wantKey   string
+		wantLevel Level
+	}{
+		{
+			name:    "missing key",
+			wantErr: ErrMissingAPIKey,
+		},
+		{
+			name:    "sandbox key in production",
+			opts:    []Option{WithAPIKey("sk_test_123"), WithEnvironment(Production)},
+			wantErr: ErrSandboxKey,
+		},
+		{
+			name:      "sandbox by default",
+			opts:      []Option{WithAPIKey("sk_test_123")},
+			wantURL:   "https://api-sandbox.spendjuice.com",
+			wantKey:   "Bearer sk_test_123",
+			wantLevel: LevelDebug,
+		},
+		{
+			name:      "production",
+			opts:      []Option{WithAPIKey("Bearer sk_live_123"), WithEnvironment(Production)},
+			wantURL:   "https://api.spendjuice.com",
+			wantKey:   "Bearer sk_live_123",
+			wantLevel: LevelInfo,
+		},
+		{
+			name:      "base URL override",
+			opts:      []Option{WithAPIKey("key"), WithBaseURL("http://127.0.0.1:8080/"), WithHTTPClient(mock)},
+			wantURL:   "http://127.0.0.1:8080",
+			wantKey:   "Bearer key",
+			wantLevel: LevelDebug,
+		},
+		{
+			name:    "timeout on a custom HTTPClient",
+			opts:    []Option{WithAPIKey("key"), WithHTTPClient(mock), WithTimeout(time.Second)},
+			wantErr: errors.New("juice: WithTimeout needs WithHTTPClient to be given an *http.Client"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewClient(tt.opts...)
+			if tt.wantErr != nil {
+				if err == nil || err.Error() != tt.wantErr.Error() {
+					t.Fatalf("NewClient() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			if got.baseURL != tt.wantURL || got.apiKey != tt.wantKey || got.logLevel != tt.wantLevel {
+				t.Errorf("NewClient() = %s %q level %s, want %s %q level %s", got.baseURL, got.apiKey, got.logLevel, tt.wantURL, tt.wantKey, tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestNewClient_timeout(t *testing.T) {
+	custom := &http.Client{Timeout: time.Minute}
+	c, err := NewClient(WithAPIKey("key"), WithHTTPClient(custom), WithTimeout(5*time.Second))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if got := c.httpClient.(*http.Client).Timeout; got != 5*time.Second {
+		t.Errorf("timeout = %s, want 5s", got)
+	}
+	if custom.Timeout != time.Minute {
+		t.Errorf("WithTimeout modified the caller's http.Client")
+	}
+}
+
+func TestNewClient_fromEnvironment(t *testing.T) {
+	defer os.Setenv("JUICE_PRIVATE_KEY", os.Getenv("JUICE_PRIVATE_KEY"))
+	defer os.Setenv("ENV", os.Getenv("ENV"))
+	os.Setenv("JUICE_PRIVATE_KEY", "sk_test_env")
+	os.Setenv("ENV", "production")
+
+	if _, err := NewClient(FromEnvironment()); !errors.Is(err, ErrSandboxKey) {
+		t.Errorf("NewClient(FromEnvironment()) error = %v, want ErrSandboxKey", err)
+	}
+	c, err := NewClient(FromEnvironment(), WithEnvironment(Sandbox))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if c.apiKey != "Bearer sk_test_env" {
+		t.Errorf("apiKey = %q, want the key from JUICE_PRIVATE_KEY", c.apiKey)
+	}
+}
@@ -0,0 +1,229 @@
+package juice
+
+import (
+	"context"
+	er "errors"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned by a client set to fail fast when a request
+// would exceed its rate limit.
+var ErrRateLimited = er.New("juice: client-side rate limit exceeded")
+
+// EndpointClass groups endpoints that share a rate limit budget.
+type EndpointClass int
+
+const (
+	// ClassRead covers GET requests.
+	ClassRead EndpointClass = iota
+	// ClassCardMutation covers requests that change a card: creating,
+	// crediting, debiting, freezing and so on.
+	ClassCardMutation
+	// ClassWrite covers every other mutating request.
+	ClassWrite
+)
+
+// classify returns the endpoint class of req.
+func classify(req *http.Request) EndpointClass {
+	if req.Method == http.MethodGet {
+		return ClassRead
+	}
+	if strings.HasPrefix(req.URL.Path, "/cards") {
+		return ClassCardMutation
+	}
+	return ClassWrite
+}
+
+// Limit is a token bucket budget: Rate requests per second on average, with
+// up to Burst requests at once. A zero Rate means no limit.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimit configures the client-side rate limiter. Every request takes a
+// token from the Global budget and from the budget of its endpoint class.
+//
+// Workers sharing one API key should share one Client so they share the
+// budgets.
+type RateLimit struct {
+	Global Limit
+	// Classes holds per endpoint class budgets. Classes left out are only
+	// held to the Global budget.
+	Classes map[EndpointClass]Limit
+	// FailFast makes requests that would have to wait for a token fail with
+	// ErrRateLimited instead of blocking.
+	FailFast bool
+}
+
+// SetRateLimit turns on client-side rate limiting. Retries count against the
+// budgets like any other request.
+//
+// When Spend-Juice answers 429, the budgets of the global limit and the
+// request's class pause for the Retry-After period, or a second without one,
+// and their rate drops by half. It recovers gradually as requests succeed.
+func (cl *Client) SetRateLimit(limit RateLimit) {
+	l := &limiter{failFast: limit.FailFast, classes: map[EndpointClass]*bucket{}, now: time.Now}
+	now := l.now()
+	if limit.Global.Rate > 0 {
+		l.global = newBucket(limit.Global, now)
+	}
+	for class, lim := range limit.Classes {
+		if lim.Rate > 0 {
+			l.classes[class] = newBucket(lim, now)
+		}
+	}
+	cl.limiter = l
+}
+
+// limiter holds the client's token buckets.
+type limiter struct {
+	mu       sync.Mutex
+	failFast bool
+	global   *bucket
+	classes  map[EndpointClass]*bucket
+	now      func() time.Time
+}
+
+// minRateFactor is how far 429 responses can drive a bucket's rate down.
+const minRateFactor = 0.1
+
+// bucket is a token bucket whose rate adapts to 429 responses.
+type bucket struct {
+	limit   Limit
+	rate    float64
+	tokens  float64
+	last    time.Time
+	blocked time.Time
+}
+
+func newBucket(limit Limit, now time.Time) *bucket {
+	if limit.Burst < 1 {
+		limit.Burst = 1
+	}
+	return &bucket{limit: limit, rate: limit.Rate, tokens: float64(limit.Burst), last: now}
+}
+
+// refill adds the tokens accrued since the last call.
+func (b *bucket) refill(now time.Time) {
+	if now.After(b.last) {
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+// delay returns how long until the bucket has a token to spare.
+func (b *bucket) delay(now time.Time) time.Duration {
+	var wait time.Duration
+	if b.tokens < 1 {
+		wait = time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	}
+	if pause := b.blocked.Sub(now); pause > wait {
+		wait = pause
+	}
+	return wait
+}
+
+// buckets returns the buckets a request of the given class draws from.
+func (l *limiter) buckets(class EndpointClass) []*bucket {
+	var bs []*bucket
+	if l.global != nil {
+		bs = append(bs, l.global)
+	}
+	if b := l.classes[class]; b != nil {
+		bs = append(bs, b)
+	}
+	return bs
+}
+
+// wait takes a token for req from every bucket it draws from, blocking until
+// they are available or failing with ErrRateLimited in fail-fast mode.
+func (l *limiter) wait(req *http.Request) error {
+	l.mu.Lock()
+	now := l.now()
+	bs := l.buckets(classify(req))
+
+	var wait time.Duration
+	for _, b := range bs {
+		b.refill(now)
+		if d := b.delay(now); d > wait {
+			wait = d
+		}
+	}
+	if wait > 0 && l.failFast {
+		l.mu.Unlock()
+		return ErrRateLimited
+	}
+	// Take the tokens now, going into debt if need be, so requests queue up
+	// in order instead of racing for the next token.
+	for _, b := range bs {
+		b.tokens--
+	}
+	l.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	if err := sleep(req.Context(), wait); err != nil {
+		l.refund(bs)
+		return err
+	}
+	return nil
+}
+
+// refund returns the tokens taken by a request that gave up waiting, so
+// requests queued behind it don't wait for a slot it never used.
+func (l *limiter) refund(bs []*bucket) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	for _, b := range bs {
+		b.refill(now)
+		b.tokens = math.Min(float64(b.limit.Burst), b.tokens+1)
+	}
+}
+
+// observe adapts the buckets req drew from to the response it got.
+func (l *limiter) observe(req *http.Request, r *http.Response) {
+	if r == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+
+	for _, b := range l.buckets(classify(req)) {
+		b.refill(now)
+		if r.StatusCode == http.StatusTooManyRequests {
+			pause, ok := parseRetryAfter(r.Header.Get("Retry-After"), now)
+			if !ok {
+				pause = time.Second
+			}
+			if until := now.Add(pause); until.After(b.blocked) {
+				b.blocked = until
+			}
+			b.rate = math.Max(b.rate/2, b.limit.Rate*minRateFactor)
+			continue
+		}
+		if b.rate < b.limit.Rate {
+			b.rate = math.Min(b.rate+b.limit.Rate*minRateFactor, b.limit.Rate)
+		}
+	}
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,117 @@
+package juice
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func rateLimitedClient(limit RateLimit, statuses ...int) (*Client, *int) {
+	calls := 0
+	c := newTestClient()
+	c.SetRetryPolicy(NoRetries)
+	c.SetRateLimit(limit)
+	c.SetHTTPClient(&MockHttpClient{
+		DoFunc: func(r *http.Request) (*http.Response, error) {
+			status := 200
+			if calls < len(statuses) {
+				status = statuses[calls]
+			}
+			calls++
+			return &http.Response{
+				StatusCode: status,
+				Header:     http.Header{"Retry-After": {"1"}},
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{}`))),
+			}, nil
+		},
+	})
+	return c, &calls
+}
+
+func TestClient_rateLimitFailFast(t *testing.T) {
+	c, calls := rateLimitedClient(RateLimit{
+		Classes:  map[EndpointClass]Limit{ClassCardMutation: {Rate: 1, Burst: 2}},
+		FailFast: true,
+	})
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.FreezeCard("card"); err != nil {
+			t.Fatalf("FreezeCard() #%d error = %v", i+1, err)
+		}
+	}
+	if _, err := c.FreezeCard("card"); !errors.Is(err, ErrRateLimited) || !IsRateLimited(err) {
+		t.Errorf("FreezeCard() over budget error = %v, want ErrRateLimited matched by IsRateLimited", err)
+	}
+	if _, err := c.GetCard("card"); err != nil {
+		t.Errorf("GetCard() error = %v, reads have their own budget", err)
+	}
+	if *calls != 3 {
+		t.Errorf("sent %d requests, want 3", *calls)
+	}
+}
+
+func TestClient_rateLimitBlocks(t *testing.T) {
+	c, _ := rateLimitedClient(RateLimit{Global: Limit{Rate: 50, Burst: 1}})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetCard("card"); err != nil {
+			t.Fatalf("GetCard() error = %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("3 calls at 50/s with a burst of 1 took %s, want at least 30ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.limiter.global.tokens = 0
+	if _, err := c.GetCardCtx(ctx, "card"); !errors.Is(err, context.Canceled) {
+		t.Errorf("GetCardCtx() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestClient_rateLimitRefundsOnCancel(t *testing.T) {
+	c, calls := rateLimitedClient(RateLimit{Global: Limit{Rate: 1, Burst: 1}})
+	now := time.Now()
+	c.limiter.now = func() time.Time { return now }
+	c.limiter.global.tokens, c.limiter.global.last = 0, now
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetCardCtx(ctx, "card"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetCardCtx() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if got := c.limiter.global.tokens; got != 0 {
+		t.Errorf("tokens after a cancelled wait = %v, want the reservation refunded to 0", got)
+	}
+	if *calls != 0 {
+		t.Errorf("sent %d requests, want none", *calls)
+	}
+}
+
+func TestClient_rateLimitAdapts(t *testing.T) {
+	c, _ := rateLimitedClient(RateLimit{Global: Limit{Rate: 100, Burst: 10}, FailFast: true}, 429)
+
+	if _, err := c.GetFloat(); !IsRateLimited(err) {
+		t.Fatalf("GetFloat() error = %v, want a 429", err)
+	}
+	if _, err := c.GetFloat(); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("GetFloat() after a 429 error = %v, want ErrRateLimited until Retry-After passes", err)
+	}
+	if got := c.limiter.global.rate; got != 50 {
+		t.Errorf("rate after a 429 = %v, want 50", got)
+	}
+
+	c.limiter.global.blocked = time.Time{}
+	if _, err := c.GetFloat(); err != nil {
+		t.Fatalf("GetFloat() error = %v", err)
+	}
+	if got := c.limiter.global.rate; got != 60 {
+		t.Errorf("rate after a success = %v, want 60", got)
+	}
+}
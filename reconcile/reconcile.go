@@ -0,0 +1,193 @@
+// Package reconcile checks a card's transaction history against itself and
+// against the card's balance.
+//
+// Every transaction records the card balance before and after it. In a
+// consistent history each step adds or removes exactly its amount, each
+// transaction starts from the balance the previous one ended on, and the
+// last one ends on the balance GetCard reports:
+//
+//	report, err := reconcile.Card(ctx, cl, cardId)
+//	if err != nil {
+//		return err
+//	}
+//	for _, issue := range report.Issues {
+//		log.Print(issue)
+//	}
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	juice "github.com/bushaHQ/spend-juice-go"
+)
+
+// Kind classifies an issue.
+type Kind string
+
+const (
+	// StepMismatch is a transaction whose balance after isn't its balance
+	// before plus or minus its amount.
+	StepMismatch Kind = "step_mismatch"
+	// Gap is a transaction that doesn't start from the balance the previous
+	// transaction ended on, e.g. because a transaction is missing.
+	Gap Kind = "gap"
+	// Duplicate is a transaction id listed more than once.
+	Duplicate Kind = "duplicate"
+	// BalanceMismatch is a closing balance different from the card balance.
+	BalanceMismatch Kind = "balance_mismatch"
+)
+
+// Issue is one inconsistency found in a history.
+type Issue struct {
+	Kind Kind
+	// TransactionId is the transaction the issue was found at. It is empty
+	// for a BalanceMismatch.
+	TransactionId string
+	// PreviousId is the transaction before a Gap.
+	PreviousId string
+	// Expected and Actual are the balances that disagree.
+	Expected juice.Money
+	Actual   juice.Money
+}
+
+func (i Issue) String() string {
+	switch i.Kind {
+	case StepMismatch:
+		return fmt.Sprintf("transaction %s: balance after is %s, want %s", i.TransactionId, i.Actual, i.Expected)
+	case Gap:
+		return fmt.Sprintf("transaction %s: starts at %s but %s ended at %s", i.TransactionId, i.Actual, i.PreviousId, i.Expected)
+	case Duplicate:
+		return fmt.Sprintf("transaction %s: listed more than once", i.TransactionId)
+	case BalanceMismatch:
+		return fmt.Sprintf("card balance is %s but the history ends at %s", i.Actual, i.Expected)
+	}
+	return string(i.Kind)
+}
+
+// Report is the outcome of reconciling one card.
+type Report struct {
+	CardId string
+	// Transactions is the number of distinct transactions checked.
+	Transactions int
+	// Opening is the balance before the first transaction and Closing the
+	// balance after the last one.
+	Opening juice.Money
+	Closing juice.Money
+	// Balance is the card balance the history was compared with.
+	Balance juice.Money
+	Issues  []Issue
+}
+
+// OK reports whether no issues were found.
+func (r Report) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// Card fetches the full transaction history and balance of a card and checks
+// them. The history is fetched first, so a transaction landing in between
+// shows up in the balance only; when the two disagree the history is fetched
+// once more before a BalanceMismatch is reported.
+func Card(ctx context.Context, cl *juice.Client, cardId string) (Report, error) {
+	history, err := cl.Transactions(ctx, cardId, 0).All()
+	if err != nil {
+		return Report{}, err
+	}
+	card, err := cl.GetCardCtx(ctx, cardId)
+	if err != nil {
+		return Report{}, err
+	}
+	report := Check(history, card.Balance)
+	if !equal(report.Closing, card.Balance) {
+		if history, err = cl.Transactions(ctx, cardId, 0).All(); err != nil {
+			return Report{}, err
+		}
+		report = Check(history, card.Balance)
+	}
+	report.CardId = cardId
+	return report, nil
+}
+
+// direction tells which way transaction types move the balance: 1 for money
+// coming in, -1 for money going out. Unknown types may go either way.
+var direction = map[juice.TransactionType]int{
+	juice.TransactionCredit:         1,
+	juice.TransactionDeductReversal: 1,
+	juice.TransactionDebit:          -1,
+	juice.TransactionDeduct:         -1,
+}
+
+// Check checks a card's history, in any order, against its balance.
+func Check(history []juice.Transaction, balance juice.Money) Report {
+	report := Report{Balance: balance, Closing: juice.NewMoney(0, balance.Currency)}
+	report.Opening = report.Closing
+
+	// The API lists transactions newest first. Reverse before sorting so
+	// transactions created in the same instant keep their order.
+	txs := make([]juice.Transaction, 0, len(history))
+	seen := map[string]bool{}
+	for i := len(history) - 1; i >= 0; i-- {
+		t := history[i]
+		if seen[t.Id] {
+			report.Issues = append(report.Issues, Issue{Kind: Duplicate, TransactionId: t.Id})
+			continue
+		}
+		seen[t.Id] = true
+		txs = append(txs, t)
+	}
+	sort.SliceStable(txs, func(i, j int) bool {
+		return txs[i].CreatedAt.Before(txs[j].CreatedAt)
+	})
+	report.Transactions = len(txs)
+
+	for i, t := range txs {
+		if i == 0 {
+			report.Opening = t.CardBalanceBefore
+		} else if prev := txs[i-1]; !equal(prev.CardBalanceAfter, t.CardBalanceBefore) {
+			report.Issues = append(report.Issues, Issue{
+				Kind:          Gap,
+				TransactionId: t.Id,
+				PreviousId:    prev.Id,
+				Expected:      prev.CardBalanceAfter,
+				Actual:        t.CardBalanceBefore,
+			})
+		}
+
+		if want, ok := stepResult(t); !ok {
+			report.Issues = append(report.Issues, Issue{
+				Kind:          StepMismatch,
+				TransactionId: t.Id,
+				Expected:      want,
+				Actual:        t.CardBalanceAfter,
+			})
+		}
+		report.Closing = t.CardBalanceAfter
+	}
+
+	if !equal(report.Closing, balance) {
+		report.Issues = append(report.Issues, Issue{Kind: BalanceMismatch, Expected: report.Closing, Actual: balance})
+	}
+	return report
+}
+
+// stepResult returns the balance t should end on and whether it does.
+func stepResult(t juice.Transaction) (juice.Money, bool) {
+	in, _ := t.CardBalanceBefore.Add(t.Amount)
+	out, _ := t.CardBalanceBefore.Sub(t.Amount)
+	switch direction[t.Type] {
+	case 1:
+		return in, equal(in, t.CardBalanceAfter)
+	case -1:
+		return out, equal(out, t.CardBalanceAfter)
+	}
+	if equal(out, t.CardBalanceAfter) {
+		return out, true
+	}
+	return in, equal(in, t.CardBalanceAfter)
+}
+
+func equal(a, b juice.Money) bool {
+	c, err := a.Cmp(b)
+	return err == nil && c == 0
+}
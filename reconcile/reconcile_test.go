@@ -0,0 +1,183 @@
+package reconcile
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	juice "github.com/bushaHQ/spend-juice-go"
+	"github.com/bushaHQ/spend-juice-go/juicetest"
+)
+
+func tx(id string, kind juice.TransactionType, minute int, amount, before, after int64) juice.Transaction {
+	return juice.Transaction{
+		Id:                id,
+		Type:              kind,
+		CreatedAt:         time.Date(2023, 6, 1, 9, minute, 0, 0, time.UTC),
+		Amount:            juice.USDCents(amount),
+		CardBalanceBefore: juice.USDCents(before),
+		CardBalanceAfter:  juice.USDCents(after),
+	}
+}
+
+func TestCheck(t *testing.T) {
+	credit := tx("t1", "credit", 0, 5000, 0, 5000)
+	debit := tx("t2", "debit", 1, 1250, 5000, 3750)
+
+	tests := []struct {
+		name    string
+		history []juice.Transaction
+		balance juice.Money
+		want    []Issue
+	}{
+		{
+			name:    "consistent history, newest first",
+			history: []juice.Transaction{debit, credit},
+			balance: juice.USDCents(3750),
+		},
+		{
+			name:    "empty history of an empty card",
+			balance: juice.USDCents(0),
+		},
+		{
+			name:    "debit that took too much",
+			history: []juice.Transaction{tx("t2", "debit", 1, 1250, 5000, 3000), credit},
+			balance: juice.USDCents(3000),
+			want:    []Issue{{Kind: StepMismatch, TransactionId: "t2", Expected: juice.USDCents(3750), Actual: juice.USDCents(3000)}},
+		},
+		{
+			name:    "missing transaction",
+			history: []juice.Transaction{tx("t3", "debit", 2, 750, 3750, 3000), credit},
+			balance: juice.USDCents(3000),
+			want:    []Issue{{Kind: Gap, TransactionId: "t3", PreviousId: "t1", Expected: juice.USDCents(5000), Actual: juice.USDCents(3750)}},
+		},
+		{
+			name:    "page overlap",
+			history: []juice.Transaction{debit, credit, credit},
+			balance: juice.USDCents(3750),
+			want:    []Issue{{Kind: Duplicate, TransactionId: "t1"}},
+		},
+		{
+			name:    "balance moved without a transaction",
+			history: []juice.Transaction{debit, credit},
+			balance: juice.USDCents(3700),
+			want:    []Issue{{Kind: BalanceMismatch, Expected: juice.USDCents(3750), Actual: juice.USDCents(3700)}},
+		},
+		{
+			name:    "unknown type may go either way",
+			history: []juice.Transaction{tx("t2", "fee", 1, 100, 5000, 4900), credit},
+			balance: juice.USDCents(4900),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Check(tt.history, tt.balance)
+			if !reflect.DeepEqual(got.Issues, tt.want) {
+				t.Errorf("Check() issues = %v, want %v", got.Issues, tt.want)
+			}
+			if got.OK() != (len(tt.want) == 0) {
+				t.Errorf("Check() OK = %v with issues %v", got.OK(), got.Issues)
+			}
+		})
+	}
+}
+
+func TestCard(t *testing.T) {
+	srv := juicetest.NewServer()
+	defer srv.Close()
+	srv.SetFloat(juice.USDCents(100000))
+	cl := srv.Client()
+
+	user, err := cl.RegisterUser(juicetest.UserData("user1@gmail.com", "+2348023547672"), "integrator")
+	if err != nil {
+		t.Fatalf("RegisterUser() error = %v", err)
+	}
+	card, err := cl.CreateCard(juicetest.CardData(user.Data.Id))
+	if err != nil {
+		t.Fatalf("CreateCard() error = %v", err)
+	}
+	cardId := card.Data.Id
+	for _, amount := range []int64{5000, 2500} {
+		if _, err := cl.CreditCard(juice.PaymentData{Source: "integrator", Amount: juice.USDCents(amount), CardId: cardId}); err != nil {
+			t.Fatalf("CreditCard() error = %v", err)
+		}
+	}
+	if _, err := cl.MockTransaction(juice.MockTransactionData{Amount: juice.USDCents(1250), Type: "debit"}, cardId); err != nil {
+		t.Fatalf("MockTransaction() error = %v", err)
+	}
+
+	report, err := Card(context.Background(), cl, cardId)
+	if err != nil {
+		t.Fatalf("Card() error = %v", err)
+	}
+	if !report.OK() || report.Transactions != 3 || report.Closing != juice.USDCents(6250) || report.Balance != juice.USDCents(6250) {
+		t.Errorf("Card() = %+v, want 3 consistent transactions ending at 62.50 USD", report)
+	}
+}
+
+func TestCard_concurrentTransaction(t *testing.T) {
+	tests := []struct {
+		name string
+		// after tells whether the transaction lands after the card lookup
+		// answers rather than before it is sent.
+		after bool
+	}{
+		{name: "lands before the balance is read"},
+		{name: "lands after the balance is read", after: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := juicetest.NewServer()
+			defer srv.Close()
+			srv.SetFloat(juice.USDCents(100000))
+			cl := srv.Client()
+
+			user, err := cl.RegisterUser(juicetest.UserData("user1@gmail.com", "+2348023547672"), "integrator")
+			if err != nil {
+				t.Fatalf("RegisterUser() error = %v", err)
+			}
+			card, err := cl.CreateCard(juicetest.CardData(user.Data.Id))
+			if err != nil {
+				t.Fatalf("CreateCard() error = %v", err)
+			}
+			cardId := card.Data.Id
+			if _, err := cl.CreditCard(juice.PaymentData{Source: "integrator", Amount: juice.USDCents(5000), CardId: cardId}); err != nil {
+				t.Fatalf("CreditCard() error = %v", err)
+			}
+
+			other, landed := srv.Client(), false
+			land := func() {
+				if landed {
+					return
+				}
+				landed = true
+				if _, err := other.MockTransaction(juice.MockTransactionData{Amount: juice.USDCents(1250), Type: "debit"}, cardId); err != nil {
+					t.Fatalf("MockTransaction() error = %v", err)
+				}
+			}
+			cl.Use(func(next juice.HTTPClient) juice.HTTPClient {
+				return juice.HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+					lookup := req.URL.Path == "/cards/"+cardId
+					if lookup && !tt.after {
+						land()
+					}
+					res, err := next.Do(req)
+					if lookup && tt.after {
+						land()
+					}
+					return res, err
+				})
+			})
+
+			report, err := Card(context.Background(), cl, cardId)
+			if err != nil {
+				t.Fatalf("Card() error = %v", err)
+			}
+			if !report.OK() {
+				t.Errorf("Card() found issues %v in a consistent card", report.Issues)
+			}
+		})
+	}
+}
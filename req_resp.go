@@ -1,64 +1,92 @@
 package juice
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type RegisterAccountData struct {
-	FloatCurrencies    []string `json:"float_currencies"`
-	BusinessAddress    string   `json:"business_address"`
-	BusinessName       string   `json:"business_name"`
-	Chain              string   `json:"chain"`
-	ContactNumber      string   `json:"contact_number"`
-	Country            string   `json:"country"`
-	Domain             string   `json:"domain"`
-	Email              string   `json:"email"`
-	FirstName          string   `json:"first_name"`
-	LastName           string   `json:"last_name"`
-	Password           string   `json:"password"`
-	RegistrationNumber string   `json:"registration_number"`
-	WebhookUrl         string   `json:"webhook_url"`
+	FloatCurrencies    []Currency `json:"float_currencies" valid:"currency"`
+	BusinessAddress    string     `json:"business_address"`
+	BusinessName       string     `json:"business_name" valid:"required"`
+	Chain              Chain      `json:"chain" valid:"required,known"`
+	ContactNumber      string     `json:"contact_number" valid:"required,e164"`
+	Country            string     `json:"country" valid:"ISO3166Alpha2"`
+	Domain             string     `json:"domain" valid:"url"`
+	Email              string     `json:"email" valid:"required,email"`
+	FirstName          string     `json:"first_name"`
+	LastName           string     `json:"last_name"`
+	Password           string     `json:"password"`
+	RegistrationNumber string     `json:"registration_number"`
+	WebhookUrl         string     `json:"webhook_url" valid:"url"`
 }
 
 type UpdateAccountData struct {
-	WebhookUrl      string `json:"webhook_url"`
+	WebhookUrl      string `json:"webhook_url" valid:"url"`
 	BusinessAddress string `json:"business_address"`
-	Domain          string `json:"domain"`
+	Domain          string `json:"domain" valid:"url"`
 }
 
 type RegisterUserData struct {
 	Address     UserAddress `json:"address"`
-	Email       string      `json:"email"`
-	FirstName   string      `json:"first_name"`
-	IdNumber    string      `json:"id_number"`
-	IdType      string      `json:"id_type"`
-	LastName    string      `json:"last_name"`
-	PhoneNumber string      `json:"phone_number"`
+	Email       string      `json:"email" valid:"required,email"`
+	FirstName   string      `json:"first_name" valid:"required"`
+	IdNumber    string      `json:"id_number" valid:"required"`
+	IdType      IdType      `json:"id_type" valid:"required,known"`
+	LastName    string      `json:"last_name" valid:"required"`
+	PhoneNumber string      `json:"phone_number" valid:"required,e164"`
 	UserPhoto   string      `json:"user_photo,omitempty"`
 }
 
+// UpdateUserData changes a card user. Fields left empty are not changed.
+type UpdateUserData struct {
+	Address     *UserAddress `json:"address,omitempty"`
+	PhoneNumber string       `json:"phone_number,omitempty" valid:"e164"`
+}
+
+// UserSearchParam finds card users by email address.
+type UserSearchParam struct {
+	Email string `url:"email" valid:"required,email"`
+	Limit int    `url:"limit,omitempty"`
+	Page  int    `url:"page,omitempty"`
+}
+
+// Param pages through a list. The API reads the lowercase limit and page
+// query keys, as ListCards sends them; the capitalised Limit and Page keys
+// the field names would give were ignored, so every page came back at the
+// API's default size.
 type Param struct {
-	Limit int `url:",omitempty"`
-	Page  int `url:",omitempty"`
+	Limit int `url:"limit,omitempty"`
+	Page  int `url:"page,omitempty"`
 }
 
 type CreateCardData struct {
-	DesignType       string `json:"design_type"`
-	SingleUse        bool   `json:"single_use"`
-	Source           string `json:"source"`
-	CardIntegratorId string `json:"card_integrator_id"`
-	Currency         string `json:"currency"`
-	UserId           string `json:"user_id"`
-	Validity         int    `json:"validity"`
+	DesignType       DesignType `json:"design_type" valid:"required,known"`
+	SingleUse        bool       `json:"single_use"`
+	Source           string     `json:"source"`
+	CardIntegratorId string     `json:"card_integrator_id"`
+	Currency         Currency   `json:"currency" valid:"required,currency"`
+	UserId           string     `json:"user_id" valid:"required"`
+	Validity         int        `json:"validity"`
+	// IdempotencyKey identifies this card order across retries. One is
+	// generated when left empty.
+	IdempotencyKey string `json:"-"`
 }
 
 type PaymentData struct {
 	Source string `json:"source"`
-	Amount int    `json:"amount"`
-	CardId string `json:"card_id"`
+	// Amount is sent as minor units only; Spend-Juice reads it in the card's
+	// currency, so it must be given in that currency.
+	Amount Money  `json:"amount" valid:"required,positive,currency"`
+	CardId string `json:"card_id" valid:"required"`
+	// IdempotencyKey identifies this payment across retries. One is
+	// generated when left empty.
+	IdempotencyKey string `json:"-"`
 }
 
 type MockTransactionData struct {
-	Amount int    `json:"amount"`
-	Type   string `json:"type"`
+	Amount Money           `json:"amount" valid:"required,positive,currency"`
+	Type   TransactionType `json:"type" valid:"required,in(debit|deduct|credit|deduct-reversal)"`
 }
 
 type AccountResp struct {
@@ -66,7 +94,9 @@ type AccountResp struct {
 }
 
 type TopUpFloatData struct {
-	Amount int `json:"amount"`
+	// Amount is sent as minor units only, in the float's currency.
+	Amount         Money  `json:"amount" valid:"required,positive,currency"`
+	IdempotencyKey string `json:"-"`
 }
 
 type UsersResp struct {
@@ -81,19 +111,25 @@ type UserResp struct {
 }
 
 type CardResp struct {
-	Balance    int       `json:"balance"`
-	CardNumber string    `json:"card_number"`
-	CardType   string    `json:"card_type"`
-	Cvv2       string    `json:"cvv2"`
-	Expiry     time.Time `json:"expiry"`
-	Id         string    `json:"id"`
-	SingleUse  bool      `json:"single_use"`
-	Status     string    `json:"status"`
-	Valid      string    `json:"valid"`
+	Balance    Money      `json:"balance"`
+	CardNumber string     `json:"card_number"`
+	CardType   CardType   `json:"card_type"`
+	Cvv2       string     `json:"cvv2"`
+	Expiry     time.Time  `json:"expiry"`
+	Id         string     `json:"id"`
+	SingleUse  bool       `json:"single_use"`
+	Status     CardStatus `json:"status"`
+	Valid      string     `json:"valid"`
+	// IdempotencyKey is the key CreditCard or DebitCard sent, whether the
+	// caller's or a generated one. It is set even when the call fails.
+	IdempotencyKey string `json:"-"`
 }
 
 type CreateCardResp struct {
 	Data Card `json:"data"`
+	// IdempotencyKey is the key CreateCard sent, whether the caller's or a
+	// generated one. It is set even when the call fails.
+	IdempotencyKey string `json:"-"`
 }
 
 type TransactionResp struct {
@@ -104,13 +140,23 @@ type TransactionResp struct {
 type TransactionsResp struct {
 	Data     []Transaction `json:"data"`
 	Message  string        `json:"message"`
-	NextPage interface{}   `json:"next_page"`
+	NextPage *int          `json:"next_page"`
 }
 
 type BalanceResp struct {
-	Balance  int    `json:"balance"`
-	Currency string `json:"currency"`
-	Id       string `json:"id"`
+	Balance  Money    `json:"balance"`
+	Currency Currency `json:"currency"`
+	Id       string   `json:"id"`
+}
+
+// UnmarshalJSON decodes a balance, tagging it with the float currency.
+func (b *BalanceResp) UnmarshalJSON(data []byte) error {
+	type balanceResp BalanceResp
+	if err := json.Unmarshal(data, (*balanceResp)(b)); err != nil {
+		return err
+	}
+	b.Balance = b.Balance.inCurrency(b.Currency)
+	return nil
 }
 
 type Resp struct {
@@ -0,0 +1,164 @@
+package juice
+
+import (
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// idempotencyKeyHeader marks a mutating request as safe to repeat.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// RetryPolicy controls how the client retries requests that fail with a
+// transport error or a transient status (429, 502, 503 and 504).
+//
+// GET requests are always eligible for retries. Mutating requests are only
+// retried when they carry an idempotency key, so a repeated call can never
+// move money twice.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// Values below 2 disable retries.
+	MaxAttempts int
+	// MinBackoff is the base delay before the first retry. It doubles on
+	// every following attempt.
+	MinBackoff time.Duration
+	// MaxBackoff caps the computed delay. A Retry-After header sent with a
+	// 429 or 503 response takes precedence over the computed delay; when it
+	// asks for longer than MaxBackoff the response is returned without
+	// retrying.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is the policy used by clients created with NewClient.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	MinBackoff:  500 * time.Millisecond,
+	MaxBackoff:  10 * time.Second,
+}
+
+// NoRetries disables retries altogether.
+var NoRetries = RetryPolicy{MaxAttempts: 1}
+
+// SetRetryPolicy overrides the retry policy used for API calls.
+func (cl *Client) SetRetryPolicy(policy RetryPolicy) {
+	cl.retryPolicy = policy
+}
+
+// allows reports whether req may be sent more than once.
+func (p RetryPolicy) allows(req *http.Request) bool {
+	if p.MaxAttempts < 2 {
+		return false
+	}
+	if req.Method == http.MethodGet {
+		return true
+	}
+	return req.Header.Get(idempotencyKeyHeader) != "" && (req.Body == nil || req.GetBody != nil)
+}
+
+// backoff returns how long to wait before the given retry attempt, and false
+// when the server asks to wait longer than MaxBackoff.
+func (p RetryPolicy) backoff(attempt int, r *http.Response) (time.Duration, bool) {
+	if r != nil && (r.StatusCode == http.StatusTooManyRequests || r.StatusCode == http.StatusServiceUnavailable) {
+		if wait, ok := parseRetryAfter(r.Header.Get("Retry-After"), time.Now()); ok {
+			return wait, p.MaxBackoff <= 0 || wait <= p.MaxBackoff
+		}
+	}
+
+	wait := p.MinBackoff
+	for i := 1; i < attempt && wait < p.MaxBackoff; i++ {
+		wait *= 2
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	if wait <= 0 {
+		return 0, true
+	}
+
+	// Jitter between half and the full delay so concurrent workers spread out.
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(wait-half)+1)), true
+}
+
+// shouldRetry reports whether an attempt failed in a way that is worth repeating.
+func shouldRetry(r *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch r.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := at.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// do sends req, retrying according to the client's retry policy.
+func (cl *Client) do(req *http.Request) (*http.Response, error) {
+	policy := cl.retryPolicy
+	retryable := policy.allows(req)
+	h := cl.transport()
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		if cl.limiter != nil {
+			if err := cl.limiter.wait(req); err != nil {
+				return nil, err
+			}
+		}
+
+		r, err := h.Do(req)
+
+		if cl.limiter != nil {
+			cl.limiter.observe(req, r)
+		}
+
+		if !retryable || attempt >= policy.MaxAttempts || !shouldRetry(r, err) || req.Context().Err() != nil {
+			return r, err
+		}
+
+		wait, ok := policy.backoff(attempt, r)
+		if !ok {
+			return r, err
+		}
+		if r != nil {
+			io.Copy(ioutil.Discard, r.Body)
+			r.Body.Close()
+		}
+
+		cl.log(LevelDebug, "retrying", Field{"method", req.Method}, Field{"path", req.URL.Path}, Field{"wait", wait}, Field{"attempt", attempt + 1}, Field{"max_attempts", policy.MaxAttempts})
+
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package juice
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClient_retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		key          string
+		retryAfter   string
+		statuses     []int
+		wantAttempts int
+		wantStatus   int
+	}{
+		{
+			name:         "GET is retried until it succeeds",
+			method:       http.MethodGet,
+			statuses:     []int{503, 502, 200},
+			wantAttempts: 3,
+			wantStatus:   200,
+		},
+		{
+			name:         "GET gives up after max attempts",
+			method:       http.MethodGet,
+			statuses:     []int{503, 503, 503, 200},
+			wantAttempts: 3,
+			wantStatus:   503,
+		},
+		{
+			name:         "GET is not retried on client errors",
+			method:       http.MethodGet,
+			statuses:     []int{404, 200},
+			wantAttempts: 1,
+			wantStatus:   404,
+		},
+		{
+			name:         "PATCH without idempotency key is not retried",
+			method:       http.MethodPatch,
+			statuses:     []int{503, 200},
+			wantAttempts: 1,
+			wantStatus:   503,
+		},
+		{
+			name:         "PATCH with idempotency key is retried",
+			method:       http.MethodPatch,
+			key:          "f3a9c1d2",
+			statuses:     []int{429, 200},
+			wantAttempts: 2,
+			wantStatus:   200,
+		},
+		{
+			name:         "Retry-After beyond MaxBackoff is returned without retrying",
+			method:       http.MethodGet,
+			retryAfter:   "86400",
+			statuses:     []int{429, 200},
+			wantAttempts: 1,
+			wantStatus:   429,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			c := newTestClient()
+			c.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond})
+			c.SetHTTPClient(&MockHttpClient{
+				DoFunc: func(r *http.Request) (*http.Response, error) {
+					if r.Body != nil {
+						body, _ := ioutil.ReadAll(r.Body)
+						if string(body) != `{"amount":100}` {
+							t.Errorf("attempt %d sent body %q", attempts+1, body)
+						}
+					}
+					status := tt.statuses[attempts]
+					attempts++
+					retryAfter := tt.retryAfter
+					if retryAfter == "" {
+						retryAfter = "0"
+					}
+					return &http.Response{
+						StatusCode: status,
+						Header:     http.Header{"Retry-After": []string{retryAfter}},
+						Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+					}, nil
+				},
+			})
+
+			var body io.Reader
+			if tt.method != http.MethodGet {
+				body = bytes.NewBufferString(`{"amount":100}`)
+			}
+			req, _ := http.NewRequestWithContext(context.Background(), tt.method, "https://juice.test/cards/credit/balance", body)
+			if tt.key != "" {
+				req.Header.Set(idempotencyKeyHeader, tt.key)
+			}
+
+			r, err := c.do(req)
+			if err != nil {
+				t.Fatalf("do() error = %v", err)
+			}
+			if attempts != tt.wantAttempts {
+				t.Errorf("do() attempts = %d, want %d", attempts, tt.wantAttempts)
+			}
+			if r.StatusCode != tt.wantStatus {
+				t.Errorf("do() status = %d, want %d", r.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func Test_parseRetryAfter(t *testing.T) {
+	now := time.Date(2022, 4, 17, 20, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		wantOk bool
+	}{
+		{name: "seconds", value: "7", want: 7 * time.Second, wantOk: true},
+		{name: "a day", value: "86400", want: 24 * time.Hour, wantOk: true},
+		{name: "http date", value: "Sun, 17 Apr 2022 20:00:30 GMT", want: 30 * time.Second, wantOk: true},
+		{name: "date in the past", value: "Sun, 17 Apr 2022 19:00:00 GMT", want: 0, wantOk: true},
+		{name: "empty", value: "", wantOk: false},
+		{name: "garbage", value: "soon", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRetryAfter(tt.value, now)
+			if ok != tt.wantOk || got != tt.want {
+				t.Errorf("parseRetryAfter() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
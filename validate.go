@@ -0,0 +1,128 @@
+package juice
+
+import (
+	er "errors"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	valid "github.com/asaskevich/govalidator"
+)
+
+// ValidationError is returned, before any request is sent, when a payload
+// breaks the field rules in its valid tags. Fields lists every failing field
+// by its JSON name, e.g. "address.country".
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + " " + f.Message
+	}
+	return "juice: invalid request: " + strings.Join(msgs, "; ")
+}
+
+// IsValidation reports whether err is a ValidationError.
+func IsValidation(err error) bool {
+	var e *ValidationError
+	return er.As(err, &e)
+}
+
+// e164 matches international phone numbers such as +2348012345678.
+var e164 = regexp.MustCompile(`^\+[1-9][0-9]{7,14}$`)
+
+func init() {
+	valid.CustomTypeTagMap.Set("e164", func(i interface{}, _ interface{}) bool {
+		s, ok := i.(string)
+		return ok && e164.MatchString(s)
+	})
+	valid.CustomTypeTagMap.Set("positive", func(i interface{}, _ interface{}) bool {
+		m, ok := i.(Money)
+		return ok && m.Amount > 0
+	})
+	valid.CustomTypeTagMap.Set("known", func(i interface{}, _ interface{}) bool {
+		e, ok := i.(interface{ Known() bool })
+		return ok && e.Known()
+	})
+	valid.CustomTypeTagMap.Set("currency", func(i interface{}, _ interface{}) bool {
+		switch c := i.(type) {
+		case Currency:
+			return c == "" || c.Known()
+		case Money:
+			return c.Currency.Known()
+		case []Currency:
+			for _, c := range c {
+				if !c.Known() {
+					return false
+				}
+			}
+			return true
+		}
+		return false
+	})
+}
+
+// messages replaces govalidator's messages for the validators used in tags.
+var messages = map[string]string{
+	"required":      "is required",
+	"email":         "must be a valid email address",
+	"e164":          "must be a phone number in international format, e.g. +2348012345678",
+	"ISO3166Alpha2": "must be a two-letter ISO 3166 country code",
+	"url":           "must be a valid URL",
+	"positive":      "must be greater than zero",
+	"currency":      "must be a supported currency",
+	"known":         "must be one of the values this package knows",
+}
+
+// validate checks params against its valid tags.
+func validate(params interface{}) error {
+	if _, err := valid.ValidateStruct(params); err != nil {
+		var fields []FieldError
+		t := reflect.Indirect(reflect.ValueOf(params)).Type()
+		collect(t, err, &fields)
+		if len(fields) == 0 {
+			return err
+		}
+		sort.SliceStable(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+func collect(t reflect.Type, err error, fields *[]FieldError) {
+	switch e := err.(type) {
+	case valid.Errors:
+		for _, err := range e {
+			collect(t, err, fields)
+		}
+	case valid.Error:
+		msg, ok := messages[e.Validator]
+		if !ok {
+			msg = e.Err.Error()
+		}
+		*fields = append(*fields, FieldError{Field: jsonPath(t, append(e.Path, e.Name)), Message: msg})
+	}
+}
+
+// jsonPath turns the Go field names of a path through t into JSON names.
+func jsonPath(t reflect.Type, path []string) string {
+	names := make([]string, len(path))
+	for i, name := range path {
+		names[i] = name
+		if t.Kind() != reflect.Struct {
+			continue
+		}
+		f, ok := t.FieldByName(name)
+		if !ok {
+			continue
+		}
+		if tag := strings.Split(f.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+			names[i] = tag
+		}
+		t = f.Type
+	}
+	return strings.Join(names, ".")
+}
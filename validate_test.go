@@ -0,0 +1,170 @@
+package juice
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		params interface{}
+		want   []FieldError
+	}{
+		{
+			name: "valid account",
+			params: RegisterAccountData{
+				BusinessName: "Algoro", Email: "boro@gmail.com", ContactNumber: "+2349034384669", Country: "NG",
+				Domain: "https://boro.com", FloatCurrencies: []Currency{USD}, Chain: ChainETH,
+			},
+		},
+		{
+			name:   "account with every field wrong",
+			params: RegisterAccountData{Email: "boro", ContactNumber: "09034384669", Country: "Nigeria", WebhookUrl: "not a url", FloatCurrencies: []Currency{"XYZ"}, Chain: "BTC"},
+			want: []FieldError{
+				{Field: "business_name", Message: "is required"},
+				{Field: "chain", Message: messages["known"]},
+				{Field: "contact_number", Message: messages["e164"]},
+				{Field: "country", Message: messages["ISO3166Alpha2"]},
+				{Field: "email", Message: messages["email"]},
+				{Field: "float_currencies", Message: messages["currency"]},
+				{Field: "webhook_url", Message: messages["url"]},
+			},
+		},
+		{
+			name:   "account without a chain",
+			params: RegisterAccountData{BusinessName: "Algoro", Email: "boro@gmail.com", ContactNumber: "+2349034384669"},
+			want:   []FieldError{{Field: "chain", Message: "is required"}},
+		},
+		{
+			name:   "account update with a bad webhook",
+			params: &UpdateAccountData{WebhookUrl: "not a url", Domain: "https://boro.com"},
+			want:   []FieldError{{Field: "webhook_url", Message: messages["url"]}},
+		},
+		{
+			name:   "valid user",
+			params: validUser(),
+		},
+		{
+			name:   "empty user",
+			params: RegisterUserData{},
+			want: []FieldError{
+				{Field: "address.city", Message: "is required"},
+				{Field: "address.country", Message: "is required"},
+				{Field: "address.line1", Message: "is required"},
+				{Field: "email", Message: "is required"},
+				{Field: "first_name", Message: "is required"},
+				{Field: "id_number", Message: "is required"},
+				{Field: "id_type", Message: "is required"},
+				{Field: "last_name", Message: "is required"},
+				{Field: "phone_number", Message: "is required"},
+			},
+		},
+		{
+			name: "user with a bad address",
+			params: func() RegisterUserData {
+				u := validUser()
+				u.Address.Country = "NGA"
+				return u
+			}(),
+			want: []FieldError{{Field: "address.country", Message: messages["ISO3166Alpha2"]}},
+		},
+		{
+			name: "user with an unknown id type",
+			params: func() RegisterUserData {
+				u := validUser()
+				u.IdType = "DRIVERS_LICENSE"
+				return u
+			}(),
+			want: []FieldError{{Field: "id_type", Message: messages["known"]}},
+		},
+		{
+			name:   "valid card",
+			params: CreateCardData{UserId: "user", Currency: USD, DesignType: DesignAurora},
+		},
+		{
+			name:   "empty card",
+			params: CreateCardData{},
+			want: []FieldError{
+				{Field: "currency", Message: "is required"},
+				{Field: "design_type", Message: "is required"},
+				{Field: "user_id", Message: "is required"},
+			},
+		},
+		{
+			name:   "card with unknown values",
+			params: CreateCardData{UserId: "user", Currency: "usd", DesignType: "Nebula"},
+			want:   []FieldError{{Field: "currency", Message: messages["currency"]}, {Field: "design_type", Message: messages["known"]}},
+		},
+		{
+			name:   "empty payment",
+			params: PaymentData{Amount: USDCents(-100)},
+			want:   []FieldError{{Field: "amount", Message: messages["positive"]}, {Field: "card_id", Message: "is required"}},
+		},
+		{
+			name:   "payment in an unsupported currency",
+			params: PaymentData{Amount: NewMoney(100, "XYZ"), CardId: "card"},
+			want:   []FieldError{{Field: "amount", Message: messages["currency"]}},
+		},
+		{
+			name:   "top-up without a currency",
+			params: TopUpFloatData{Amount: NewMoney(500000, "")},
+			want:   []FieldError{{Field: "amount", Message: messages["currency"]}},
+		},
+		{
+			name:   "unsupported mock type",
+			params: MockTransactionData{Amount: USDCents(100), Type: "refund"},
+			want:   []FieldError{{Field: "type", Message: "refund does not validate as in(debit|deduct|credit|deduct-reversal)"}},
+		},
+		{
+			name:   "zero top-up",
+			params: &TopUpFloatData{},
+			want:   []FieldError{{Field: "amount", Message: "is required"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate(tt.params)
+			var got []FieldError
+			if err != nil {
+				e, ok := err.(*ValidationError)
+				if !ok {
+					t.Fatalf("validate() error = %T %v, want *ValidationError", err, err)
+				}
+				got = e.Fields
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validate() fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidate_beforeSending(t *testing.T) {
+	cl := newTestClient()
+	cl.SetHTTPClient(HTTPClientFunc(func(r *http.Request) (*http.Response, error) {
+		t.Fatalf("request sent for an invalid payload: %s %s", r.Method, r.URL.Path)
+		return nil, nil
+	}))
+
+	_, err := cl.CreditCard(PaymentData{Source: "integrator"})
+	if !IsValidation(err) {
+		t.Fatalf("CreditCard() error = %v, want a ValidationError", err)
+	}
+	if want := "juice: invalid request: amount is required; card_id is required"; err.Error() != want {
+		t.Errorf("CreditCard() error = %q, want %q", err, want)
+	}
+}
+
+func validUser() RegisterUserData {
+	return RegisterUserData{
+		Address:     UserAddress{City: "Lagos", Country: "NG", Line1: "Lekki Phase 1"},
+		Email:       "user1@gmail.com",
+		FirstName:   "Ada",
+		IdNumber:    "00000000000",
+		IdType:      IdBVN,
+		LastName:    "Obi",
+		PhoneNumber: "+2348023547672",
+	}
+}
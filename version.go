@@ -0,0 +1,63 @@
+package juice
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+)
+
+// APIVersion sets the X-Api-Version request header to version.
+func APIVersion(version string) Middleware {
+	return func(next HTTPClient) HTTPClient {
+		return HTTPClientFunc(func(req *http.Request) (*http.Response, error) {
+			req.Header.Set(apiVersionHeader, version)
+			return next.Do(req)
+		})
+	}
+}
+
+// ResponseDecoder decodes the body of a successful response into v.
+type ResponseDecoder func(body []byte, v interface{}) error
+
+// SetResponseDecoder decodes responses the server labels with version using
+// decode instead of the default JSON decoding. Use it to keep your code on
+// the shapes it expects while Spend-Juice migrates an endpoint, e.g. by
+// translating renamed fields before unmarshaling.
+func (cl *Client) SetResponseDecoder(version string, decode ResponseDecoder) {
+	if cl.decoders == nil {
+		cl.decoders = map[string]ResponseDecoder{}
+	}
+	cl.decoders[version] = decode
+}
+
+// ServerVersion returns the API version reported by the last response, or ""
+// if the server hasn't reported one yet.
+func (cl *Client) ServerVersion() string {
+	v, _ := cl.serverVersion.Load().(string)
+	return v
+}
+
+// decoder returns how to decode a response reporting version. A response
+// without a version is decoded as the version the client is pinned to.
+func (cl *Client) decoder(version string) ResponseDecoder {
+	if version == "" {
+		version = cl.apiVersion
+	}
+	if dec, ok := cl.decoders[version]; ok {
+		return dec
+	}
+	return decodeJSON
+}
+
+// observeVersion remembers the version reported in r.
+func (cl *Client) observeVersion(r *http.Response) string {
+	version := r.Header.Get(apiVersionHeader)
+	if version != "" {
+		cl.serverVersion.Store(version)
+	}
+	return version
+}
+
+func decodeJSON(body []byte, v interface{}) error {
+	return json.NewDecoder(bytes.NewReader(body)).Decode(v)
+}
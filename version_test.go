@@ -0,0 +1,84 @@
+package juice
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestClient_apiVersion(t *testing.T) {
+	var sent []string
+	c, err := NewClient(WithAPIKey("sk_test_juice"), WithAPIVersion("2023-06-01"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	c.SetHTTPClient(&MockHttpClient{
+		DoFunc: func(r *http.Request) (*http.Response, error) {
+			sent = append(sent, r.Header.Get("X-Api-Version"))
+			body := `{"balance":100,"currency":"USD"}`
+			if r.Header.Get("X-Api-Version") == "2024-01-01" {
+				body = `{"float_balance":100,"currency":"USD"}`
+			}
+			return &http.Response{
+				StatusCode: 200,
+				Header:     http.Header{"X-Api-Version": {r.Header.Get("X-Api-Version")}},
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+			}, nil
+		},
+	})
+
+	if c.ServerVersion() != "" {
+		t.Errorf("ServerVersion() = %q before any response", c.ServerVersion())
+	}
+	if _, err := c.GetFloat(); err != nil {
+		t.Fatalf("GetFloat() error = %v", err)
+	}
+	if sent[0] != "2023-06-01" || c.ServerVersion() != "2023-06-01" {
+		t.Errorf("sent version %q, server reported %q, want 2023-06-01", sent[0], c.ServerVersion())
+	}
+
+	// The 2024-01-01 API renamed balance to float_balance.
+	c.SetAPIVersion("2024-01-01")
+	c.SetResponseDecoder("2024-01-01", func(body []byte, v interface{}) error {
+		return json.Unmarshal([]byte(strings.Replace(string(body), `"float_balance"`, `"balance"`, 1)), v)
+	})
+	got, err := c.GetFloat()
+	if err != nil {
+		t.Fatalf("GetFloat() error = %v", err)
+	}
+	if got.Balance != USDCents(100) || c.ServerVersion() != "2024-01-01" {
+		t.Errorf("GetFloat() = %v with server version %q, want 1.00 USD from 2024-01-01", got.Balance, c.ServerVersion())
+	}
+}
+
+func TestClient_apiVersionNotEchoed(t *testing.T) {
+	c, err := NewClient(WithAPIKey("sk_test_juice"), WithAPIVersion("2024-01-01"))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	c.SetHTTPClient(&MockHttpClient{
+		DoFunc: func(r *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: 200,
+				Body:       ioutil.NopCloser(bytes.NewReader([]byte(`{"float_balance":100,"currency":"USD"}`))),
+			}, nil
+		},
+	})
+	c.SetResponseDecoder("2024-01-01", func(body []byte, v interface{}) error {
+		return json.Unmarshal([]byte(strings.Replace(string(body), `"float_balance"`, `"balance"`, 1)), v)
+	})
+
+	got, err := c.GetFloat()
+	if err != nil {
+		t.Fatalf("GetFloat() error = %v", err)
+	}
+	if got.Balance != USDCents(100) {
+		t.Errorf("GetFloat() = %v, want 1.00 USD decoded as the pinned 2024-01-01", got.Balance)
+	}
+	if c.ServerVersion() != "" {
+		t.Errorf("ServerVersion() = %q, want none reported", c.ServerVersion())
+	}
+}
@@ -0,0 +1,122 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	er "errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader carries the delivery signature, formatted as
+//
+//	t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<raw body>">
+//
+// Several v1 values may be present while a secret is being rotated.
+const SignatureHeader = "Juice-Signature"
+
+// DefaultTolerance is how far a delivery timestamp may drift from the local
+// clock before the delivery is treated as a replay.
+const DefaultTolerance = 5 * time.Minute
+
+var (
+	ErrNoSecret         = er.New("webhook: no signing secret configured")
+	ErrNoSignature      = er.New("webhook: delivery is not signed")
+	ErrMalformedHeader  = er.New("webhook: malformed signature header")
+	ErrInvalidSignature = er.New("webhook: signature does not match")
+	ErrTimestampTooOld  = er.New("webhook: signature timestamp outside tolerance")
+)
+
+// SignatureError is returned when a delivery fails verification. Err is one of
+// the Err* values above, so callers can use errors.Is.
+type SignatureError struct {
+	Err       error
+	Timestamp time.Time
+}
+
+func (e *SignatureError) Error() string {
+	if e.Timestamp.IsZero() {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s (signed at %s)", e.Err, e.Timestamp.UTC().Format(time.RFC3339))
+}
+
+func (e *SignatureError) Unwrap() error {
+	return e.Err
+}
+
+// Sign returns the SignatureHeader value for body signed with secret at t. It
+// is what Spend-Juice sends, and lets tests build valid deliveries locally.
+func Sign(body []byte, secret string, t time.Time) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac(ts, body, secret))
+}
+
+// Verify checks header against body and secret, rejecting deliveries signed
+// more than tolerance away from now.
+func Verify(body []byte, header, secret string, tolerance time.Duration) error {
+	return verify(body, header, secret, tolerance, time.Now())
+}
+
+func verify(body []byte, header, secret string, tolerance time.Duration, now time.Time) error {
+	if secret == "" {
+		return &SignatureError{Err: ErrNoSecret}
+	}
+	if header == "" {
+		return &SignatureError{Err: ErrNoSignature}
+	}
+
+	var ts string
+	var signatures [][]byte
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			return &SignatureError{Err: ErrMalformedHeader}
+		}
+		switch kv[0] {
+		case "t":
+			ts = kv[1]
+		case "v1":
+			sig, err := hex.DecodeString(kv[1])
+			if err != nil {
+				return &SignatureError{Err: ErrMalformedHeader}
+			}
+			signatures = append(signatures, sig)
+		}
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return &SignatureError{Err: ErrMalformedHeader}
+	}
+	signedAt := time.Unix(unix, 0)
+
+	expected := mac(ts, body, secret)
+	matched := false
+	for _, sig := range signatures {
+		if hmac.Equal(sig, expected) {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return &SignatureError{Err: ErrInvalidSignature, Timestamp: signedAt}
+	}
+
+	if drift := now.Sub(signedAt); tolerance > 0 && (drift > tolerance || drift < -tolerance) {
+		return &SignatureError{Err: ErrTimestampTooOld, Timestamp: signedAt}
+	}
+
+	return nil
+}
+
+func mac(ts string, body []byte, secret string) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(ts))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
@@ -0,0 +1,100 @@
+package webhook
+
+import (
+	"context"
+	er "errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":"evt_1","event":"card.frozen","data":{}}`)
+	signedAt := time.Date(2022, 4, 17, 20, 55, 36, 0, time.UTC)
+	valid := Sign(body, "whsec_test", signedAt)
+
+	tests := []struct {
+		name    string
+		body    []byte
+		header  string
+		secret  string
+		now     time.Time
+		wantErr error
+	}{
+		{name: "valid", body: body, header: valid, secret: "whsec_test", now: signedAt.Add(time.Minute)},
+		{name: "rotated secret", body: body, header: valid + ",v1=00ff", secret: "whsec_test", now: signedAt},
+		{name: "unsigned", body: body, header: "", secret: "whsec_test", now: signedAt, wantErr: ErrNoSignature},
+		{name: "wrong secret", body: body, header: valid, secret: "whsec_other", now: signedAt, wantErr: ErrInvalidSignature},
+		{name: "tampered body", body: []byte(`{"id":"evt_1","event":"card.unfrozen","data":{}}`), header: valid, secret: "whsec_test", now: signedAt, wantErr: ErrInvalidSignature},
+		{name: "replayed", body: body, header: valid, secret: "whsec_test", now: signedAt.Add(time.Hour), wantErr: ErrTimestampTooOld},
+		{name: "from the future", body: body, header: valid, secret: "whsec_test", now: signedAt.Add(-time.Hour), wantErr: ErrTimestampTooOld},
+		{name: "no timestamp", body: body, header: "v1=00ff", secret: "whsec_test", now: signedAt, wantErr: ErrMalformedHeader},
+		{name: "not hex", body: body, header: "t=1650228936,v1=zz", secret: "whsec_test", now: signedAt, wantErr: ErrMalformedHeader},
+		{name: "no secret", body: body, header: valid, secret: "", now: signedAt, wantErr: ErrNoSecret},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verify(tt.body, tt.header, tt.secret, DefaultTolerance, tt.now)
+			if !er.Is(err, tt.wantErr) {
+				t.Fatalf("verify() error = %v, want %v", err, tt.wantErr)
+			}
+			var sigErr *SignatureError
+			if err != nil && !er.As(err, &sigErr) {
+				t.Errorf("verify() error is %T, want *SignatureError", err)
+			}
+		})
+	}
+}
+
+func TestHandler_rejectsUnverifiedDeliveries(t *testing.T) {
+	body := `{"id":"evt_1","event":"card.frozen","data":{"id":"0c7ca765"}}`
+	h := NewHandler("whsec_test")
+	h.OnCardFrozen(func(ctx context.Context, e CardEvent) error {
+		t.Errorf("callback called for an unverified delivery")
+		return nil
+	})
+
+	for name, header := range map[string]string{
+		"unsigned": "",
+		"stale":    Sign([]byte(body), "whsec_test", time.Now().Add(-time.Hour)),
+		"forged":   Sign([]byte(body), "guessed", time.Now()),
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/juice", strings.NewReader(body))
+			req.Header.Set(SignatureHeader, header)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("ServeHTTP() status = %d, want %d", rec.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+}
+
+func TestHandler_emptySecret(t *testing.T) {
+	body := `{"id":"evt_1","event":"card.frozen","data":{"id":"0c7ca765"}}`
+	tests := []struct {
+		name     string
+		handler  *Handler
+		wantCode int
+	}{
+		{name: "NewHandler rejects", handler: NewHandler(""), wantCode: http.StatusUnauthorized},
+		{name: "NewUnverifiedHandler accepts", handler: NewUnverifiedHandler(), wantCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/webhooks/juice", strings.NewReader(body))
+			req.Header.Set(SignatureHeader, Sign([]byte(body), "", time.Now()))
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, req)
+			if rec.Code != tt.wantCode {
+				t.Errorf("ServeHTTP() status = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if tt.wantCode == http.StatusUnauthorized && !strings.Contains(rec.Body.String(), ErrNoSecret.Error()) {
+				t.Errorf("ServeHTTP() body = %q, want %q", rec.Body.String(), ErrNoSecret)
+			}
+		})
+	}
+}
@@ -0,0 +1,277 @@
+// Package webhook receives Spend-Juice webhook deliveries and dispatches them
+// to typed callbacks.
+//
+// Every delivery is a JSON envelope:
+//
+//	{
+//		"id": "evt_…",
+//		"event": "transaction.authorized",
+//		"created_at": "2022-04-17T20:55:36.798Z",
+//		"data": { … }
+//	}
+//
+// Card events carry a juice.Card in data, transaction events a juice.Transaction
+// plus "card_id" and, for declines, "reason", and float events the funded
+// "amount" with the resulting "balance" and "currency".
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	er "errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	juice "github.com/bushaHQ/spend-juice-go"
+)
+
+// maxBodySize bounds how much of a delivery is read.
+const maxBodySize = 1 << 20
+
+// EventType names a kind of webhook delivery.
+type EventType string
+
+const (
+	CardCreated           EventType = "card.created"
+	CardFrozen            EventType = "card.frozen"
+	CardUnfrozen          EventType = "card.unfrozen"
+	TransactionAuthorized EventType = "transaction.authorized"
+	TransactionDeclined   EventType = "transaction.declined"
+	FloatFunded           EventType = "float.funded"
+)
+
+// Event is the envelope shared by every delivery. Data holds the raw event body.
+type Event struct {
+	Id        string          `json:"id"`
+	Type      EventType       `json:"event"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// CardEvent is delivered for CardCreated, CardFrozen and CardUnfrozen.
+type CardEvent struct {
+	Event
+	Card juice.Card
+}
+
+// TransactionEvent is delivered for TransactionAuthorized and TransactionDeclined.
+type TransactionEvent struct {
+	Event
+	CardId      string
+	Transaction juice.Transaction
+	// Reason explains a decline. It is empty for authorizations.
+	Reason string
+}
+
+// FloatEvent is delivered for FloatFunded.
+type FloatEvent struct {
+	Event
+	Amount  juice.Money
+	Balance juice.Money
+}
+
+// Payload is implemented by Event and by every typed event embedding it.
+type Payload interface {
+	envelope() Event
+}
+
+func (e Event) envelope() Event {
+	return e
+}
+
+// Parse decodes a delivery body into its typed event. Deliveries of an
+// unknown type are returned as a plain Event so newer events never fail.
+func Parse(body []byte) (Payload, error) {
+	var e Event
+	if err := json.Unmarshal(body, &e); err != nil {
+		return nil, fmt.Errorf("webhook: malformed delivery: %w", err)
+	}
+	if e.Type == "" {
+		return nil, er.New("webhook: delivery has no event type")
+	}
+
+	switch e.Type {
+	case CardCreated, CardFrozen, CardUnfrozen:
+		ev := CardEvent{Event: e}
+		if err := json.Unmarshal(e.Data, &ev.Card); err != nil {
+			return nil, fmt.Errorf("webhook: malformed %s data: %w", e.Type, err)
+		}
+		return ev, nil
+
+	case TransactionAuthorized, TransactionDeclined:
+		ev := TransactionEvent{Event: e}
+		var extra struct {
+			CardId string `json:"card_id"`
+			Reason string `json:"reason"`
+		}
+		if err := json.Unmarshal(e.Data, &ev.Transaction); err != nil {
+			return nil, fmt.Errorf("webhook: malformed %s data: %w", e.Type, err)
+		}
+		if err := json.Unmarshal(e.Data, &extra); err != nil {
+			return nil, fmt.Errorf("webhook: malformed %s data: %w", e.Type, err)
+		}
+		ev.CardId, ev.Reason = extra.CardId, extra.Reason
+		return ev, nil
+
+	case FloatFunded:
+		ev := FloatEvent{Event: e}
+		var data struct {
+			Amount   int64          `json:"amount"`
+			Balance  int64          `json:"balance"`
+			Currency juice.Currency `json:"currency"`
+		}
+		if err := json.Unmarshal(e.Data, &data); err != nil {
+			return nil, fmt.Errorf("webhook: malformed %s data: %w", e.Type, err)
+		}
+		ev.Amount = juice.NewMoney(data.Amount, data.Currency)
+		ev.Balance = juice.NewMoney(data.Balance, data.Currency)
+		return ev, nil
+	}
+
+	return e, nil
+}
+
+// Handler is an http.Handler that verifies and parses deliveries and calls the
+// callbacks registered for their event type. Deliveries failing verification
+// are answered with 401. A callback error makes the handler answer 500 so
+// Spend-Juice redelivers the event.
+type Handler struct {
+	secret     string
+	unverified bool
+	tolerance  time.Duration
+	now        func() time.Time
+
+	mu        sync.RWMutex
+	callbacks map[EventType][]func(context.Context, Payload) error
+	fallback  []func(context.Context, Event) error
+}
+
+// NewHandler creates a Handler verifying deliveries against the webhook
+// signing secret. With an empty secret every delivery is rejected with
+// ErrNoSecret, so a missing secret never turns verification off.
+func NewHandler(secret string) *Handler {
+	return &Handler{
+		secret:    secret,
+		tolerance: DefaultTolerance,
+		now:       time.Now,
+		callbacks: map[EventType][]func(context.Context, Payload) error{},
+	}
+}
+
+// NewUnverifiedHandler creates a Handler that accepts deliveries without
+// checking their signature. It is only meant for local development.
+func NewUnverifiedHandler() *Handler {
+	h := NewHandler("")
+	h.unverified = true
+	return h
+}
+
+// SetTolerance overrides how old a delivery's signature may be. Zero disables
+// the timestamp check.
+func (h *Handler) SetTolerance(tolerance time.Duration) {
+	h.tolerance = tolerance
+}
+
+func (h *Handler) on(t EventType, fn func(context.Context, Payload) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.callbacks[t] = append(h.callbacks[t], fn)
+}
+
+// OnCardCreated registers fn for CardCreated events.
+func (h *Handler) OnCardCreated(fn func(context.Context, CardEvent) error) {
+	h.on(CardCreated, func(ctx context.Context, p Payload) error { return fn(ctx, p.(CardEvent)) })
+}
+
+// OnCardFrozen registers fn for CardFrozen events.
+func (h *Handler) OnCardFrozen(fn func(context.Context, CardEvent) error) {
+	h.on(CardFrozen, func(ctx context.Context, p Payload) error { return fn(ctx, p.(CardEvent)) })
+}
+
+// OnCardUnfrozen registers fn for CardUnfrozen events.
+func (h *Handler) OnCardUnfrozen(fn func(context.Context, CardEvent) error) {
+	h.on(CardUnfrozen, func(ctx context.Context, p Payload) error { return fn(ctx, p.(CardEvent)) })
+}
+
+// OnTransactionAuthorized registers fn for TransactionAuthorized events.
+func (h *Handler) OnTransactionAuthorized(fn func(context.Context, TransactionEvent) error) {
+	h.on(TransactionAuthorized, func(ctx context.Context, p Payload) error { return fn(ctx, p.(TransactionEvent)) })
+}
+
+// OnTransactionDeclined registers fn for TransactionDeclined events.
+func (h *Handler) OnTransactionDeclined(fn func(context.Context, TransactionEvent) error) {
+	h.on(TransactionDeclined, func(ctx context.Context, p Payload) error { return fn(ctx, p.(TransactionEvent)) })
+}
+
+// OnFloatFunded registers fn for FloatFunded events.
+func (h *Handler) OnFloatFunded(fn func(context.Context, FloatEvent) error) {
+	h.on(FloatFunded, func(ctx context.Context, p Payload) error { return fn(ctx, p.(FloatEvent)) })
+}
+
+// OnAny registers fn for every delivery, including event types this package
+// does not know about yet.
+func (h *Handler) OnAny(fn func(context.Context, Event) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fallback = append(h.fallback, fn)
+}
+
+// Dispatch calls the callbacks registered for p, stopping at the first error.
+func (h *Handler) Dispatch(ctx context.Context, p Payload) error {
+	e := p.envelope()
+
+	h.mu.RLock()
+	callbacks := h.callbacks[e.Type]
+	fallback := h.fallback
+	h.mu.RUnlock()
+
+	for _, fn := range callbacks {
+		if err := fn(ctx, p); err != nil {
+			return err
+		}
+	}
+	for _, fn := range fallback {
+		if err := fn(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, "unable to read body", http.StatusBadRequest)
+		return
+	}
+
+	if !h.unverified {
+		if err := verify(body, r.Header.Get(SignatureHeader), h.secret, h.tolerance, h.now()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	p, err := Parse(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Dispatch(r.Context(), p); err != nil {
+		http.Error(w, "event not processed", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
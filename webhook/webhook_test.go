@@ -0,0 +1,150 @@
+package webhook
+
+import (
+	"context"
+	er "errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	juice "github.com/bushaHQ/spend-juice-go"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    Payload
+		wantErr bool
+	}{
+		{
+			name: "card frozen",
+			body: `{"id":"evt_1","event":"card.frozen","created_at":"2022-04-17T20:55:36Z",
+				"data":{"id":"0c7ca765-764c-4f62-9c35-ac3e2abcee01","status":"frozen","balance":20000,"currency":"USD"}}`,
+			want: CardEvent{
+				Card: juice.Card{Id: "0c7ca765-764c-4f62-9c35-ac3e2abcee01", Status: "frozen", Balance: juice.USDCents(20000), Currency: "USD"},
+			},
+		},
+		{
+			name: "transaction declined",
+			body: `{"id":"evt_2","event":"transaction.declined","created_at":"2022-04-17T20:55:36Z",
+				"data":{"id":"9b14c12e","amount":100,"currency":"USD","type":"debit","card_id":"0c7ca765","reason":"insufficient funds"}}`,
+			want: TransactionEvent{
+				CardId: "0c7ca765",
+				Reason: "insufficient funds",
+				Transaction: juice.Transaction{
+					Id:                "9b14c12e",
+					Amount:            juice.USDCents(100),
+					CardBalanceAfter:  juice.USDCents(0),
+					CardBalanceBefore: juice.USDCents(0),
+					Currency:          "USD",
+					Type:              "debit",
+				},
+			},
+		},
+		{
+			name: "float funded",
+			body: `{"id":"evt_3","event":"float.funded","created_at":"2022-04-17T20:55:36Z",
+				"data":{"amount":500000,"balance":4445110,"currency":"USD"}}`,
+			want: FloatEvent{Amount: juice.USDCents(500000), Balance: juice.USDCents(4445110)},
+		},
+		{
+			name: "unknown event",
+			body: `{"id":"evt_4","event":"card.renamed","created_at":"2022-04-17T20:55:36Z","data":{}}`,
+			want: Event{},
+		},
+		{
+			name:    "missing event type",
+			body:    `{"id":"evt_5","data":{}}`,
+			wantErr: true,
+		},
+		{
+			name:    "not json",
+			body:    `<html></html>`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if reflect.TypeOf(got) != reflect.TypeOf(tt.want) {
+				t.Fatalf("Parse() returned %T, want %T", got, tt.want)
+			}
+			if got.envelope().CreatedAt != time.Date(2022, 4, 17, 20, 55, 36, 0, time.UTC) {
+				t.Errorf("Parse() created_at = %v", got.envelope().CreatedAt)
+			}
+			switch want := tt.want.(type) {
+			case CardEvent:
+				if !reflect.DeepEqual(got.(CardEvent).Card, want.Card) {
+					t.Errorf("Parse() card = %+v, want %+v", got.(CardEvent).Card, want.Card)
+				}
+			case TransactionEvent:
+				g := got.(TransactionEvent)
+				if g.CardId != want.CardId || g.Reason != want.Reason || !reflect.DeepEqual(g.Transaction, want.Transaction) {
+					t.Errorf("Parse() = %+v, want %+v", g, want)
+				}
+			case FloatEvent:
+				g := got.(FloatEvent)
+				if g.Amount != want.Amount || g.Balance != want.Balance {
+					t.Errorf("Parse() = %+v, want %+v", g, want)
+				}
+			}
+		})
+	}
+}
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	authorized := `{"id":"evt_1","event":"transaction.authorized","data":{"id":"9b14c12e","amount":100,"card_id":"0c7ca765"}}`
+
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		callback   error
+		wantStatus int
+		wantCalls  int
+	}{
+		{name: "dispatches to callbacks", method: http.MethodPost, body: authorized, wantStatus: http.StatusOK, wantCalls: 1},
+		{name: "callback failure asks for redelivery", method: http.MethodPost, body: authorized, callback: er.New("db down"), wantStatus: http.StatusInternalServerError, wantCalls: 1},
+		{name: "rejects malformed deliveries", method: http.MethodPost, body: `{`, wantStatus: http.StatusBadRequest},
+		{name: "rejects other methods", method: http.MethodGet, wantStatus: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			h := NewHandler("whsec_test")
+			h.OnTransactionAuthorized(func(ctx context.Context, e TransactionEvent) error {
+				calls++
+				if e.CardId != "0c7ca765" || e.Transaction.Amount != juice.USDCents(100) {
+					t.Errorf("callback got %+v", e)
+				}
+				return tt.callback
+			})
+			h.OnTransactionDeclined(func(ctx context.Context, e TransactionEvent) error {
+				t.Errorf("declined callback called for %s", e.Type)
+				return nil
+			})
+
+			req := httptest.NewRequest(tt.method, "/webhooks/juice", strings.NewReader(tt.body))
+			req.Header.Set(SignatureHeader, Sign([]byte(tt.body), "whsec_test", time.Now()))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("ServeHTTP() status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("ServeHTTP() callback calls = %d, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}